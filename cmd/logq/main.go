@@ -0,0 +1,211 @@
+// Command logq filters and summarises log files written by the log package. It reads text,
+// JSON and logfmt lines from the given files, or from STDIN if there are none.
+//
+//	logq -level warn -since 2h -file csv.go -grep 'row \d+' app.log
+//	logq -f -level error app.log
+//	logq -summary app.log
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wlbr/commons/log"
+)
+
+// A filter selects the records to be shown.
+type filter struct {
+	level log.LogLevel
+	since time.Time
+	until time.Time
+	file  string
+	re    *regexp.Regexp
+}
+
+func (f *filter) match(r *log.Record, text string) bool {
+	if r.Level > f.level {
+		return false
+	}
+	if !f.since.IsZero() && r.Time.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !r.Time.Before(f.until) {
+		return false
+	}
+	if f.file != "" && !strings.Contains(r.File+":"+strconv.Itoa(r.Line), f.file) {
+		return false
+	}
+	return f.re == nil || f.re.MatchString(text)
+}
+
+// summary counts the records per level and per caller.
+type summary struct {
+	levels  map[log.LogLevel]int
+	callers map[string]int
+	total   int
+}
+
+func (s *summary) add(r *log.Record) {
+	s.total++
+	s.levels[r.Level]++
+	caller := "???"
+	if r.File != "" {
+		caller = r.File + ":" + strconv.Itoa(r.Line)
+	}
+	s.callers[caller]++
+}
+
+func (s *summary) write(w io.Writer) {
+	fmt.Fprintf(w, "%d messages\n", s.total)
+	for level := log.FATAL; level < log.ALL; level++ {
+		if n := s.levels[level]; n > 0 {
+			fmt.Fprintf(w, "%7d %s\n", n, level)
+		}
+	}
+	callers := make([]string, 0, len(s.callers))
+	for c := range s.callers {
+		callers = append(callers, c)
+	}
+	sort.Slice(callers, func(i, j int) bool {
+		if s.callers[callers[i]] != s.callers[callers[j]] {
+			return s.callers[callers[i]] > s.callers[callers[j]]
+		}
+		return callers[i] < callers[j]
+	})
+	fmt.Fprintln(w, "callers:")
+	for _, c := range callers {
+		fmt.Fprintf(w, "%7d %s\n", s.callers[c], c)
+	}
+}
+
+// query reads the records from in and writes the lines of the matching ones to out, or
+// counts them if sum is not nil. Lines that are not log lines and do not belong to a record
+// are skipped. In follow mode query waits for new lines at the end of in instead of
+// returning.
+func query(in io.Reader, out io.Writer, f *filter, sum *summary, follow bool) error {
+	rd := log.NewReader(in)
+	if follow {
+		rd = log.NewFollowingReader(in)
+	}
+	for {
+		r, err := rd.Read()
+		var lerr *log.LineError
+		switch {
+		case err == io.EOF && follow:
+			time.Sleep(250 * time.Millisecond)
+			continue
+		case err == io.EOF:
+			return nil
+		case errors.Is(err, log.ErrNoLogLine):
+			continue
+		case errors.As(err, &lerr):
+			fmt.Fprintf(os.Stderr, "logq: %s\n", lerr)
+			continue
+		case err != nil:
+			return err
+		}
+		if !f.match(r, rd.Text()) {
+			continue
+		}
+		if sum != nil {
+			sum.add(r)
+		} else {
+			fmt.Fprintln(out, rd.Text())
+		}
+	}
+}
+
+// parseTime accepts RFC3339 times, dates, 'date time' and durations meaning that long ago.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, time.DateTime, "2006-01-02T15:04:05", time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s', expecting RFC3339, a date or a duration", s)
+}
+
+func main() {
+	var levelname, since, until, grep string
+	var follow, sum bool
+	f := &filter{}
+	flag.StringVar(&levelname, "level", "all", "Shows messages of this level and more severe ones. [All|Trace|Debug|Info|Warn|Error|Fatal].")
+	flag.StringVar(&since, "since", "", "Shows messages logged at or after this time, e.g. '2024-01-02T15:04:05Z', '2024-01-02' or '2h' for two hours ago.")
+	flag.StringVar(&until, "until", "", "Shows messages logged before this time, same formats as -since.")
+	flag.StringVar(&f.file, "file", "", "Shows messages logged from source files containing this text, e.g. 'csv.go' or 'csv.go:42'.")
+	flag.StringVar(&grep, "grep", "", "Shows messages whose line matches this regular expression.")
+	flag.BoolVar(&follow, "f", false, "Follows the file, waiting for new lines like 'tail -f'.")
+	flag.BoolVar(&sum, "summary", false, "Shows the number of matching messages per level and per caller instead of the messages.")
+	flag.Parse()
+
+	var err error
+	if f.level, err = log.LogLevelString(strings.ToUpper(levelname)); err != nil {
+		fail("unknown level '%s'", levelname)
+	}
+	now := time.Now()
+	if f.since, err = parseTime(since, now); err != nil {
+		fail("%s", err)
+	}
+	if f.until, err = parseTime(until, now); err != nil {
+		fail("%s", err)
+	}
+	if grep != "" {
+		if f.re, err = regexp.Compile(grep); err != nil {
+			fail("invalid regular expression: %s", err)
+		}
+	}
+	if follow && (sum || flag.NArg() != 1) {
+		fail("-f requires exactly one file and can not be combined with -summary")
+	}
+
+	var s *summary
+	if sum {
+		s = &summary{levels: map[log.LogLevel]int{}, callers: map[string]int{}}
+	}
+	out := bufio.NewWriter(os.Stdout)
+	if flag.NArg() == 0 {
+		err = query(os.Stdin, out, f, s, false)
+	}
+	for _, name := range flag.Args() {
+		file, oerr := os.Open(name)
+		if oerr != nil {
+			fail("%s", oerr)
+		}
+		if follow {
+			err = query(file, os.Stdout, f, s, true)
+		} else {
+			err = query(file, out, f, s, false)
+		}
+		file.Close()
+		if err != nil {
+			break
+		}
+	}
+	if s != nil {
+		s.write(out)
+	}
+	out.Flush()
+	if err != nil {
+		fail("%s", err)
+	}
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "logq: "+format+"\n", args...)
+	os.Exit(2)
+}
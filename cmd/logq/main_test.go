@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wlbr/commons/log"
+)
+
+const input = ` WARN: 2024/01/02 03:04:05.000006 /src/csv.go:12: first row 7
+goroutine 1 [running]:
+ERROR: 2024/01/02 03:04:06.000000 /src/csv.go:40: second
+ INFO: 2024/01/02 03:05:06.000000 /src/main.go:3: third
+{"time":"2024-01-02T03:04:07Z","level":"ERROR","file":"/src/b.go","line":3,"msg":"fourth"}
+`
+
+func TestQuery(t *testing.T) {
+	var out bytes.Buffer
+	f := &filter{level: log.WARN, file: "csv.go", re: regexp.MustCompile(`row \d`)}
+	if err := query(strings.NewReader(input), &out, f, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Join(strings.Split(input, "\n")[:2], "\n") + "\n"; out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+
+	out.Reset()
+	f = &filter{level: log.ALL, re: regexp.MustCompile(`goroutine \d`)}
+	if err := query(strings.NewReader("not a log line\n"+input), &out, f, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Join(strings.Split(input, "\n")[:2], "\n") + "\n"; out.String() != want {
+		t.Errorf("Expected the whole record matching its second line %q, got %q", want, out.String())
+	}
+
+	out.Reset()
+	since, _ := parseTime("2024-01-02T03:04:06Z", time.Now())
+	s := &summary{levels: map[log.LogLevel]int{}, callers: map[string]int{}}
+	if err := query(strings.NewReader(input), &out, &filter{level: log.ALL, since: since}, s, false); err != nil {
+		t.Fatal(err)
+	}
+	s.write(&out)
+	for _, want := range []string{"3 messages\n", "      2 ERROR\n", "      1 INFO\n", "      1 /src/main.go:3\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in summary:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	if got, _ := parseTime("2h", now); !got.Equal(now.Add(-2 * time.Hour)) {
+		t.Errorf("Unexpected time for duration: %s", got)
+	}
+	if got, _ := parseTime("2024-01-02", now); !got.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected time for date: %s", got)
+	}
+	if _, err := parseTime("yesterday", now); err == nil {
+		t.Errorf("Expected an error")
+	}
+}
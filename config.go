@@ -3,7 +3,11 @@ package commons
 import (
 	"flag"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
@@ -18,9 +22,30 @@ type CommonConfig struct {
 	ActiveLogLevel   log.LogLevel
 	logLevel         string
 	LogFileName      string
+	LogFormat        string
 	Logger           *log.Logger
 	WorkingDirectory string
-	colouredLogging  bool
+	colourMode       log.ColourMode
+	logTheme         string
+	logTemplate      string
+	logTimezone      string
+	logOTLPFile      string
+	logMaxSize       int
+	logRotate        string
+	logKeep          int
+	logCompress      bool
+	logReopen        bool
+	LogSinkName      string
+	logSinkLevel     string
+	logSinkFormat    string
+	logSinkColour    log.ColourMode
+	logDevMode       bool
+	logBuffer        int
+	logBufferDrop    bool
+	logCrashBuffer   int
+	logErrorExit     int
+	logSignals       bool
+	logAdminAddr     string
 	cleanup          []func() error
 }
 
@@ -35,10 +60,11 @@ func (cfg CommonConfig) String() string {
 		"\tGitVersion: %s\n"+
 		"\tActiveLogLevel: %+v\n"+
 		"\tLogFileName: %s\n"+
+		"\tLogFormat: %s\n"+
 		"\tLogger: %v\n"+
 		"\tWorking Directory: %s\n",
 		cfg.BuildTimeStamp, cfg.GitVersion, cfg.ActiveLogLevel.String(),
-		logfname, cfg.Logger, cfg.WorkingDirectory)
+		logfname, cfg.LogFormat, cfg.Logger, cfg.WorkingDirectory)
 }
 
 // GetInspectData offers some additional debugging information
@@ -52,10 +78,31 @@ func (cfg CommonConfig) GetInspectData() string {
 }
 
 func (cfg *CommonConfig) FlagDefinition() {
-	flag.StringVar(&cfg.logLevel, "loglevel", "Warn", "Determines logging verbosity. [All|Info|Debug|Warn|Error|Fatal|Off].")
+	flag.StringVar(&cfg.logLevel, "loglevel", "Warn", "Determines logging verbosity. [All|Trace|Debug|Info|Warn|Error|Fatal|Off], optionally followed by levels for named loggers, e.g. 'warn,csv=debug,astar=off'.")
 	flag.StringVar(&cfg.LogFileName, "logfile", "", "Sets the name of the logfile. Uses STDERR if empty.")
+	flag.StringVar(&cfg.LogFormat, "logformat", "text", "Sets the format of the log output. [text|json|logfmt].")
+	flag.IntVar(&cfg.logMaxSize, "logmaxsize", 0, "Rotates the logfile when it exceeds the given size in MB. 0 switches size based rotation off.")
+	flag.StringVar(&cfg.logRotate, "logrotate", "none", "Rotates the logfile periodically. [none|hourly|daily].")
+	flag.IntVar(&cfg.logKeep, "logkeep", 0, "Number of rotated logfiles to keep. 0 keeps all of them.")
+	flag.BoolVar(&cfg.logCompress, "logcompress", false, "Compress rotated logfiles using gzip.")
+	flag.BoolVar(&cfg.logReopen, "logreopen", false, "Reopen the logfile on SIGHUP (for use with logrotate).")
+	flag.StringVar(&cfg.LogSinkName, "logsink", "", "Sets the name of a second log destination, e.g. STDERR, syslog, syslog://host:514 or journald. Unused if empty.")
+	flag.StringVar(&cfg.logSinkLevel, "logsinklevel", "All", "Determines logging verbosity of the second log destination. [All|Trace|Debug|Info|Warn|Error|Fatal|Off].")
+	flag.StringVar(&cfg.logSinkFormat, "logsinkformat", "text", "Sets the format of the second log destination. [text|json|logfmt].")
+	flag.Var(&cfg.logSinkColour, "logsinkcolour", "Use coloured logging for the second log destination. [auto|on|off]. (default auto)")
+	flag.BoolVar(&cfg.logDevMode, "logdevmode", false, "Developer mode, always show debug messages regardless of the log level.")
+	flag.IntVar(&cfg.logBuffer, "logbuffer", 0, "Number of log messages buffered for asynchronous writing, per destination. 0 writes synchronously.")
+	flag.BoolVar(&cfg.logBufferDrop, "logbufferdrop", false, "Drop log messages instead of waiting when the log buffer is full.")
+	flag.IntVar(&cfg.logCrashBuffer, "logcrashbuffer", 0, "Number of recent log messages of all levels kept in memory and written when an error is logged. 0 switches the crash buffer off.")
+	flag.IntVar(&cfg.logErrorExit, "logerrorexit", 0, "Exit code used by Exit if errors were logged. 0 keeps the exit code 0.")
+	flag.StringVar(&cfg.logOTLPFile, "logotlp", "", "Writes the log messages additionally as OTLP/JSON into the given rolling file, e.g. for an OpenTelemetry collector. Unused if empty.")
+	flag.BoolVar(&cfg.logSignals, "logsignals", false, "Increase the log level on SIGUSR1 and decrease it on SIGUSR2.")
+	flag.StringVar(&cfg.logAdminAddr, "logadmin", "", "Serves GET/PUT /loglevel on the given local address, e.g. 'localhost:6060'. Unused if empty.")
 	flag.BoolVar(&cfg.ShowVersion, "version", false, "Show version info.")
-	flag.BoolVar(&cfg.colouredLogging, "logcolour", true, "Use coloured logging (switch of when redirecting log output).")
+	flag.Var(&cfg.colourMode, "logcolour", "Use coloured logging. [auto|on|off]. Auto colours terminals only and honours NO_COLOR and FORCE_COLOR. (default auto)")
+	flag.StringVar(&cfg.logTemplate, "logtemplate", "", "Sets the layout of text log lines, e.g. '{time:RFC3339} {level} {file:short}:{line} {func} {msg}'. Uses the classic layout if empty.")
+	flag.StringVar(&cfg.logTimezone, "logtimezone", "utc", "Sets the time zone of the times written using -logtemplate. [utc|local|<IANA name>].")
+	flag.StringVar(&cfg.logTheme, "logtheme", "", "Sets the colours of the log levels, e.g. 'error=lightRed+bold,fatal=white+bg:red'.")
 }
 
 func (cfg *CommonConfig) Initialize(version string, buildtimestamp string) *CommonConfig {
@@ -71,15 +118,22 @@ func (cfg *CommonConfig) Initialize(version string, buildtimestamp string) *Comm
 		flag.Parse()
 	}
 	// Settig up the logger
-	cfg.ActiveLogLevel, err = log.LogLevelString(strings.ToUpper(cfg.logLevel))
+	var overrides map[string]log.LogLevel
+	cfg.ActiveLogLevel, overrides, err = log.ParseLevelSpec(cfg.logLevel, log.WARN)
 	if err != nil {
 		cfg.ActiveLogLevel = log.ALL
 	}
-	cfg.Logger = log.NewLogger(cfg.LogFileName, cfg.ActiveLogLevel, cfg.colouredLogging)
+	log.SetLevelOverrides(overrides)
+	log.SetDeveloperMode(cfg.logDevMode)
+	warnings := cfg.setupLogger()
 	cfg.Logger.SetConvenienceLogger()
+	log.AddExitHook(cfg.CleanUp)
 	log.Debug("Current working directory is '%s'.", cfg.WorkingDirectory)
 	if err != nil {
-		log.Warn("Error in config, Loglevel '%s' not existing in tools/loglevel.go. Setting LogLevel to 'All'", cfg.logLevel)
+		log.Warn("Error in config, Loglevel '%s' not valid (%s). Setting LogLevel to 'All'", cfg.logLevel, err)
+	}
+	for _, w := range warnings {
+		log.Warn("Error in config, %s", w)
 	}
 
 	if cfg.ShowVersion {
@@ -94,18 +148,216 @@ func (cfg *CommonConfig) Initialize(version string, buildtimestamp string) *Comm
 	return cfg
 }
 
+// setupLogger creates cfg.Logger according to the logging flags. Configuration errors are
+// returned as warnings, because they can only be logged once the logger exists.
+func (cfg *CommonConfig) setupLogger() (warnings []string) {
+	encoder, err := log.EncoderFor(cfg.LogFormat)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("%s. Using format 'text'", err))
+	}
+	rotation, err := log.ParseRotation(cfg.logRotate)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("%s. Using rotation 'none'", err))
+	}
+	if cfg.logTemplate != "" {
+		if e, err := cfg.templateEncoder(); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s. Using the classic layout", err))
+		} else {
+			encoder = e
+		}
+	}
+	if cfg.logTheme != "" {
+		theme, err := log.ParseTheme(cfg.logTheme)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s. Using the default colours", err))
+		}
+		log.SetTheme(theme)
+	}
+
+	var logfile io.WriteCloser
+	var openerr error
+	if !log.IsConsoleName(cfg.LogFileName) {
+		if cfg.logMaxSize > 0 || rotation != log.RotateNever {
+			logfile, openerr = log.NewRotatingWriter(cfg.LogFileName, int64(cfg.logMaxSize)*1024*1024, rotation, cfg.logKeep, cfg.logCompress)
+		} else if cfg.logReopen {
+			logfile, openerr = log.NewReopenableFile(cfg.LogFileName)
+		}
+		if openerr != nil {
+			warnings = append(warnings, fmt.Sprintf("cannot open logfile: %s", openerr))
+			logfile = nil
+		}
+	}
+	if logfile == nil {
+		if cfg.colourMode == log.ColourAuto {
+			cfg.Logger = log.NewLogger(cfg.LogFileName, cfg.ActiveLogLevel)
+		} else {
+			cfg.Logger = log.NewLogger(cfg.LogFileName, cfg.ActiveLogLevel, cfg.colourMode == log.ColourOn)
+		}
+		cfg.Logger.Encoder = encoder
+	} else {
+		cfg.Logger = log.NewLoggerWithEncoder(logfile, cfg.ActiveLogLevel, log.ColourEnabled(cfg.colourMode, logfile), encoder)
+	}
+	if cfg.logBuffer > 0 {
+		cfg.Logger.EnableAsync(cfg.logBuffer, cfg.overflowPolicy())
+	}
+	if cfg.logCrashBuffer > 0 {
+		cfg.Logger.SetCrashBuffer(cfg.logCrashBuffer)
+	}
+	if r, ok := logfile.(log.Reopener); ok && cfg.logReopen {
+		warnings = append(warnings, cfg.reopenOnSignal(r)...)
+	}
+	if cfg.LogSinkName != "" {
+		warnings = append(warnings, cfg.setupSink()...)
+	}
+	if cfg.logOTLPFile != "" {
+		if err := cfg.setupOTLP(rotation); err != nil {
+			warnings = append(warnings, fmt.Sprintf("cannot open OTLP logfile: %s", err))
+		}
+	}
+	if cfg.logSignals {
+		warnings = append(warnings, cfg.stepLevelOnSignals()...)
+	}
+	if cfg.logAdminAddr != "" {
+		if err := cfg.serveLogAdmin(); err != nil {
+			warnings = append(warnings, fmt.Sprintf("cannot serve log level endpoint: %s", err))
+		}
+	}
+	return warnings
+}
+
+// templateEncoder creates the encoder for the -logtemplate and -logtimezone flags.
+func (cfg *CommonConfig) templateEncoder() (log.Encoder, error) {
+	if f := strings.ToLower(cfg.LogFormat); f != "" && f != "text" {
+		return nil, fmt.Errorf("a log template requires the format 'text', not '%s'", cfg.LogFormat)
+	}
+	loc, err := log.ParseLocation(cfg.logTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone '%s'", cfg.logTimezone)
+	}
+	return log.NewTemplateEncoder(cfg.logTemplate, loc)
+}
+
+// setupSink adds the second log destination given by the -logsink flags to cfg.Logger.
+func (cfg *CommonConfig) setupSink() (warnings []string) {
+	level, err := log.LogLevelString(strings.ToUpper(cfg.logSinkLevel))
+	if err != nil {
+		level = log.ALL
+		warnings = append(warnings, fmt.Sprintf("Loglevel '%s' of the log sink not existing. Setting it to 'All'", cfg.logSinkLevel))
+	}
+	encoder, err := log.EncoderFor(cfg.logSinkFormat)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("%s. Using format 'text' for the log sink", err))
+	}
+	sink, err := log.NewSinkFromName(cfg.LogSinkName, level, false, encoder)
+	if err != nil {
+		return append(warnings, fmt.Sprintf("cannot open log sink: %s", err))
+	}
+	sink.UseColouredOutput = log.ColourEnabled(cfg.logSinkColour, sink.Out)
+	cfg.bufferSink(sink)
+	cfg.Logger.AddSink(sink)
+	return warnings
+}
+
+// setupOTLP adds a sink writing OTLP/JSON log records into a rolling file. It rotates like
+// the logfile, but at 100 MB at the latest. Build and version are written as resource
+// attributes.
+func (cfg *CommonConfig) setupOTLP(rotation log.Rotation) error {
+	maxSize := cfg.logMaxSize
+	if maxSize <= 0 || maxSize > 100 {
+		maxSize = 100
+	}
+	w, err := log.NewRotatingWriter(cfg.logOTLPFile, int64(maxSize)*1024*1024, rotation, cfg.logKeep, cfg.logCompress)
+	if err != nil {
+		return err
+	}
+	host, _ := os.Hostname()
+	enc := log.NewOTLPEncoder("service.name", filepath.Base(os.Args[0]), "service.version", cfg.GitVersion,
+		"build.timestamp", cfg.BuildTimeStamp.Format(time.RFC3339), "host.name", host, "process.pid", os.Getpid())
+	sink := log.NewSink(w, cfg.ActiveLogLevel, false, enc)
+	// Follows runtime level changes and the levels of named loggers.
+	sink.FollowLoggerLevel = true
+	cfg.bufferSink(sink)
+	cfg.Logger.AddSink(sink)
+	return nil
+}
+
+// bufferSink makes a sink asynchronous, just like the logfile, if -logbuffer is set.
+func (cfg *CommonConfig) bufferSink(sink *log.Sink) {
+	if cfg.logBuffer > 0 {
+		sink.EnableAsync(cfg.logBuffer, cfg.overflowPolicy())
+	}
+}
+
+// overflowPolicy returns the policy of the log buffers given by -logbufferdrop.
+func (cfg *CommonConfig) overflowPolicy() log.OverflowPolicy {
+	if cfg.logBufferDrop {
+		return log.Drop
+	}
+	return log.Block
+}
+
+// serveLogAdmin serves the log level endpoint (see log.LevelHandler) on cfg.logAdminAddr.
+func (cfg *CommonConfig) serveLogAdmin() error {
+	ln, err := net.Listen("tcp", cfg.logAdminAddr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/loglevel", log.LevelHandler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Error("Log level endpoint stopped: %s", err)
+		}
+	}()
+	cfg.AddCleanUpFn(srv.Close)
+	return nil
+}
+
+// CleanUp logs the number of messages per level, as warning if errors were logged, and runs
+// the functions registered by AddCleanUpFn in the order of registration. The logger set up
+// by Initialize is closed afterwards, so it flushes everything logged by the clean ups.
 func (cfg *CommonConfig) CleanUp() {
+	if cfg.Logger != nil {
+		if stats := cfg.Logger.Stats(); stats.Errors() > 0 {
+			log.Warn("Logged %s.", stats)
+		} else {
+			log.Info("Logged %s.", stats)
+		}
+	}
 	log.Debug("Cleaning up.")
 	for _, fun := range cfg.cleanup {
 		fun()
 	}
+	if cfg.Logger != nil {
+		cfg.Logger.Close()
+	}
 }
 
 func (cfg *CommonConfig) AddCleanUpFn(f func() error) {
 	cfg.cleanup = append(cfg.cleanup, f)
 }
 
+// ExitCode returns the code given by -logerrorexit, if errors were logged, 0 otherwise.
+func (cfg *CommonConfig) ExitCode() int {
+	if cfg.Logger != nil && cfg.Logger.Stats().Errors() > 0 {
+		return cfg.logErrorExit
+	}
+	return 0
+}
+
+// Exit runs the clean up functions and exits with ExitCode, so errors logged by a program
+// may result in a non-zero exit code. It is meant to be deferred first in main:
+//
+//	defer cfg.Exit()
+func (cfg *CommonConfig) Exit() {
+	cfg.CleanUp()
+	os.Exit(cfg.ExitCode())
+}
+
+// FatalExit runs the clean up functions and exits with the code set by
+// log.SetFatalExitCode (1 by default). See log.FatalExit to log a message and exit.
 func (cfg *CommonConfig) FatalExit() {
 	cfg.CleanUp()
-	os.Exit(1)
+	os.Exit(log.FatalExitCode())
 }
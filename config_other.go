@@ -0,0 +1,15 @@
+//go:build !unix
+
+package commons
+
+import "github.com/wlbr/commons/log"
+
+// stepLevelOnSignals is not supported, there are no SIGUSR1 and SIGUSR2 signals.
+func (cfg *CommonConfig) stepLevelOnSignals() (warnings []string) {
+	return []string{"changing the log level by signals is not supported on this platform"}
+}
+
+// reopenOnSignal is not supported, there is no SIGHUP signal.
+func (cfg *CommonConfig) reopenOnSignal(r log.Reopener) (warnings []string) {
+	return []string{"reopening the logfile on SIGHUP is not supported on this platform"}
+}
@@ -0,0 +1,50 @@
+//go:build unix
+
+package commons
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/wlbr/commons/log"
+)
+
+// stepLevelOnSignals makes the logger more verbose on SIGUSR1 and less verbose on SIGUSR2.
+func (cfg *CommonConfig) stepLevelOnSignals() (warnings []string) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for sig := range c {
+			steps := 1
+			if sig == syscall.SIGUSR2 {
+				steps = -1
+			}
+			level := cfg.Logger.StepLevel(steps)
+			log.Warn("Log level changed to '%s' on %s.", level, sig)
+		}
+	}()
+	cfg.AddCleanUpFn(func() error {
+		signal.Stop(c)
+		return nil
+	})
+	return nil
+}
+
+// reopenOnSignal reopens the logfile whenever SIGHUP is received.
+func (cfg *CommonConfig) reopenOnSignal(r log.Reopener) (warnings []string) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	go func() {
+		for range c {
+			if err := r.Reopen(); err != nil {
+				log.Error("Cannot reopen logfile '%s': %s", cfg.LogFileName, err)
+			}
+		}
+	}()
+	cfg.AddCleanUpFn(func() error {
+		signal.Stop(c)
+		return nil
+	})
+	return nil
+}
@@ -10,6 +10,8 @@ import (
 	"golang.org/x/text/number"
 )
 
+var logger = log.Named("csv")
+
 var Comma string = ";"
 var Booltrue string = "wahr"
 var Boolfalse string = "falsch"
@@ -35,7 +37,7 @@ func FormatCsvReflect(v reflect.Value) (s string) {
 			s = Boolfalse
 		}
 	default:
-		log.Warn("Unknown type: '%T'\n", v)
+		logger.Warn("Unknown type: '%T'\n", v)
 	}
 	return s
 }
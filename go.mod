@@ -1,6 +1,6 @@
 module github.com/wlbr/commons
 
-go 1.18
+go 1.21
 
 require (
 	github.com/gookit/color v1.3.0
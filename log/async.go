@@ -0,0 +1,174 @@
+package log
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy determines what an AsyncWriter does when its buffer is full.
+type OverflowPolicy int
+
+// The supported overflow policies.
+const (
+	// Block lets the logging goroutine wait until there is room in the buffer.
+	Block OverflowPolicy = iota
+	// Drop discards the message, see AsyncWriter.Dropped.
+	Drop
+)
+
+// An AsyncWriter is an io.WriteCloser that buffers writes in a bounded ring buffer and
+// writes them to the underlying writer in a background goroutine. Every call to Write is
+// kept as a whole, so lines are never torn apart. An AsyncWriter is safe for concurrent use.
+type AsyncWriter struct {
+	out     io.Writer
+	policy  OverflowPolicy
+	mu      sync.Mutex
+	cond    *sync.Cond
+	ring    [][]byte
+	head    int
+	count   int
+	writing bool
+	closed  bool
+	done    chan struct{}
+	dropped atomic.Uint64
+}
+
+// NewAsyncWriter creates an AsyncWriter buffering up to size writes for out.
+func NewAsyncWriter(out io.Writer, size int, policy OverflowPolicy) *AsyncWriter {
+	if size < 1 {
+		size = 1
+	}
+	w := &AsyncWriter{out: out, policy: policy, ring: make([][]byte, size), done: make(chan struct{})}
+	w.cond = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+// Write implements io.Writer. It returns as soon as p is buffered. If the buffer is full,
+// it either blocks or drops p, depending on the OverflowPolicy.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	b := append([]byte(nil), p...)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for w.count == len(w.ring) && !w.closed {
+		if w.policy == Drop {
+			w.dropped.Add(1)
+			return len(p), nil
+		}
+		w.cond.Wait()
+	}
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	w.ring[(w.head+w.count)%len(w.ring)] = b
+	w.count++
+	w.cond.Broadcast()
+	return len(p), nil
+}
+
+// run writes the buffered data until the AsyncWriter is closed and drained.
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	batch := make([][]byte, 0, len(w.ring))
+	w.mu.Lock()
+	for {
+		for w.count == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if w.count == 0 {
+			w.mu.Unlock()
+			return
+		}
+		batch = batch[:0]
+		for ; w.count > 0; w.count-- {
+			batch = append(batch, w.ring[w.head])
+			w.ring[w.head] = nil
+			w.head = (w.head + 1) % len(w.ring)
+		}
+		w.writing = true
+		w.cond.Broadcast()
+		w.mu.Unlock()
+		for _, b := range batch {
+			w.out.Write(b)
+		}
+		w.mu.Lock()
+		w.writing = false
+		w.cond.Broadcast()
+	}
+}
+
+// Flush waits until all buffered data is written and flushes the underlying writer.
+func (w *AsyncWriter) Flush() error {
+	w.mu.Lock()
+	for w.count > 0 || w.writing {
+		w.cond.Wait()
+	}
+	w.mu.Unlock()
+	return flushWriter(w.out)
+}
+
+// Close writes all buffered data, stops the background goroutine and closes the underlying
+// writer, unless it is the console. Writes after Close fail with os.ErrClosed.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	<-w.done
+	return closeWriter(w.out)
+}
+
+// Dropped returns the number of writes dropped because the buffer was full.
+func (w *AsyncWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// closeWriter closes a destination, if it is an io.Closer and not the console.
+func closeWriter(w io.Writer) error {
+	if c, ok := w.(io.Closer); ok && !isConsole(w) {
+		return c.Close()
+	}
+	return nil
+}
+
+// EnableAsync lets the logger write asynchronously through an AsyncWriter buffering up to
+// size messages. It has to be called before the logger is used or children are derived
+// from it. Call Flush or Close to make sure all messages are written. Only the loggers own
+// stream becomes asynchronous, sinks are made asynchronous by Sink.EnableAsync.
+func (l *Logger) EnableAsync(size int, policy OverflowPolicy) *AsyncWriter {
+	w := NewAsyncWriter(l.out, size, policy)
+	l.out = w
+	return w
+}
+
+// EnableAsync lets the Sink write asynchronously through an AsyncWriter buffering up to
+// size messages. It has to be called before the Sink is added to a Logger. Flush and Close
+// of the Logger flush and close the AsyncWriter as well.
+func (s *Sink) EnableAsync(size int, policy OverflowPolicy) *AsyncWriter {
+	w := NewAsyncWriter(s.Out, size, policy)
+	s.Out = w
+	return w
+}
+
+// Close flushes the logger, stops its asynchronous hooks and closes its stream and the
+// streams of its sinks, unless they are the console.
+func (l *Logger) Close() error {
+	err := l.Flush()
+	t := l.target()
+	t.hooks.close()
+	if cerr := closeWriter(t.out); err == nil {
+		err = cerr
+	}
+	for _, s := range t.sinks.list() {
+		if serr := s.Close(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
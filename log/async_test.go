@@ -0,0 +1,78 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// slowWriter blocks every write until it is released.
+type slowWriter struct {
+	bytes.Buffer
+	release chan struct{}
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.Buffer.Write(p)
+}
+
+func TestAsyncLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, ALL, false)
+	l.EnableAsync(8, Block)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				l.Info("message %d", i)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 800 {
+		t.Errorf("Expected 800 lines, got %d", n)
+	}
+	l.Info("after close")
+	if strings.Contains(buf.String(), "after close") {
+		t.Error("Message written after Close")
+	}
+}
+
+func TestAsyncSink(t *testing.T) {
+	out := &slowWriter{release: make(chan struct{})}
+	l := NewLoggerFromFile(&bytes.Buffer{}, ALL, false)
+	s := NewSink(out, ALL, false, nil)
+	s.EnableAsync(8, Block)
+	l.AddSink(s)
+	// Returns although the sink cannot write yet.
+	l.Info("buffered")
+	close(out.release)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "buffered") {
+		t.Errorf("Message not written: %s", out.String())
+	}
+}
+
+func TestAsyncDrop(t *testing.T) {
+	out := &slowWriter{release: make(chan struct{})}
+	w := NewAsyncWriter(out, 2, Drop)
+	for i := 0; i < 10; i++ {
+		w.Write([]byte("x\n"))
+	}
+	close(out.release)
+	w.Flush()
+	written := strings.Count(out.String(), "\n")
+	if written+int(w.Dropped()) != 10 || w.Dropped() < 6 {
+		t.Errorf("Unexpected numbers: %d written, %d dropped", written, w.Dropped())
+	}
+	w.Close()
+}
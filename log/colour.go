@@ -0,0 +1,211 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gookit/color"
+)
+
+// ColourMode determines whether the output is coloured.
+type ColourMode int
+
+// The supported colour modes.
+const (
+	// ColourAuto colours the output if it is a terminal, see ColourEnabled.
+	ColourAuto ColourMode = iota
+	// ColourOn always colours the output.
+	ColourOn
+	// ColourOff never colours the output.
+	ColourOff
+)
+
+// ParseColourMode parses "auto", "on" or "off". "true", "yes", "always" and "false", "no",
+// "never" are accepted as well.
+func ParseColourMode(s string) (ColourMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "auto", "":
+		return ColourAuto, nil
+	case "on", "true", "yes", "always", "1":
+		return ColourOn, nil
+	case "off", "false", "no", "never", "0":
+		return ColourOff, nil
+	}
+	return ColourAuto, fmt.Errorf("unknown colour mode '%s'", s)
+}
+
+// String implements flag.Value.
+func (m *ColourMode) String() string {
+	if m == nil {
+		return "auto"
+	}
+	switch *m {
+	case ColourOn:
+		return "on"
+	case ColourOff:
+		return "off"
+	}
+	return "auto"
+}
+
+// Set implements flag.Value.
+func (m *ColourMode) Set(s string) error {
+	mode, err := ParseColourMode(s)
+	if err == nil {
+		*m = mode
+	}
+	return err
+}
+
+// IsBoolFlag lets a ColourMode flag be given without a value, meaning "on".
+func (m *ColourMode) IsBoolFlag() bool {
+	return true
+}
+
+// ColourEnabled reports whether output to w is coloured in the given mode. In ColourAuto
+// mode colours are switched off if the environment variable NO_COLOR is set and switched on
+// if FORCE_COLOR is set (see no-color.org), otherwise w is coloured if it is a terminal.
+func ColourEnabled(mode ColourMode, w io.Writer) bool {
+	switch mode {
+	case ColourOn:
+		return true
+	case ColourOff:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("FORCE_COLOR"); force != "" && force != "0" && force != "false" {
+		return true
+	}
+	return isTerminal(w)
+}
+
+// isTerminal reports whether w is a character device, i.e. a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// -----------------------------
+
+// A Theme maps a LogLevel to the style of its prefix. Styles are combinations of a
+// foreground colour, a background colour and options like bold. Levels missing in the
+// Theme are not coloured.
+type Theme map[LogLevel]color.Style
+
+// defaultTheme is the palette used unless SetTheme was called.
+var defaultTheme = Theme{
+	FATAL: {color.FgRed},
+	ERROR: {color.FgLightRed},
+	WARN:  {color.FgYellow},
+	INFO:  {color.FgLightCyan},
+	DEBUG: {color.FgLightBlue},
+	TRACE: {color.FgGray},
+}
+
+var theme atomic.Pointer[Theme]
+
+// DefaultTheme returns a copy of the default Theme.
+func DefaultTheme() Theme {
+	t := make(Theme, len(defaultTheme))
+	for level, style := range defaultTheme {
+		t[level] = style
+	}
+	return t
+}
+
+// SetTheme sets the Theme used for coloured output. A nil Theme restores the default.
+func SetTheme(t Theme) {
+	if t == nil {
+		theme.Store(nil)
+		return
+	}
+	theme.Store(&t)
+}
+
+func currentTheme() Theme {
+	if t := theme.Load(); t != nil {
+		return *t
+	}
+	return defaultTheme
+}
+
+// ParseTheme parses a Theme like "error=lightRed+bold,fatal=white+bg:red,debug=none",
+// starting from the default Theme. A style is a '+' separated list of a foreground colour,
+// a background colour prefixed by 'bg:' and the options bold, italic, underscore, blink
+// and reverse. 'none' removes the colour of a level. The colour names are those of
+// github.com/gookit/color, e.g. red, lightRed, darkGray. Names are case insensitive.
+func ParseTheme(spec string) (Theme, error) {
+	t := DefaultTheme()
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		levelname, stylespec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid theme entry '%s', expecting level=style", entry)
+		}
+		level, err := LogLevelString(strings.ToUpper(strings.TrimSpace(levelname)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid level in theme entry '%s': %w", entry, err)
+		}
+		style, err := parseStyle(stylespec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid style in theme entry '%s': %w", entry, err)
+		}
+		t[level] = style
+	}
+	return t, nil
+}
+
+func parseStyle(spec string) (color.Style, error) {
+	var style color.Style
+	for _, part := range strings.Split(spec, "+") {
+		part = strings.TrimSpace(part)
+		if strings.EqualFold(part, "none") {
+			continue
+		}
+		var c color.Color
+		var ok bool
+		if bg, isBg := strings.CutPrefix(part, "bg:"); isBg {
+			c, ok = lookupColour(bg, color.BgColors, color.ExBgColors)
+		} else {
+			c, ok = lookupColour(part, color.FgColors, color.ExFgColors, color.Options)
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown colour or option '%s'", part)
+		}
+		style = append(style, c)
+	}
+	return style, nil
+}
+
+func lookupColour(name string, maps ...map[string]color.Color) (color.Color, bool) {
+	for _, m := range maps {
+		for n, c := range m {
+			if strings.EqualFold(n, name) {
+				return c, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// colorize renders s in the style of the level according to the current Theme. The escape
+// codes are written regardless of the terminal type, the caller decides on colouring.
+func colorize(level LogLevel, s string) string {
+	style := currentTheme()[level]
+	if len(style) == 0 {
+		return s
+	}
+	return fmt.Sprintf(color.FullColorTpl, style.Code(), s)
+}
@@ -0,0 +1,71 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestColourEnabled(t *testing.T) {
+	var buf bytes.Buffer
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	if ColourEnabled(ColourAuto, &buf) || !ColourEnabled(ColourOn, &buf) || ColourEnabled(ColourOff, os.Stderr) {
+		t.Errorf("Unexpected colour decision without environment")
+	}
+	t.Setenv("FORCE_COLOR", "1")
+	if !ColourEnabled(ColourAuto, &buf) {
+		t.Errorf("FORCE_COLOR should switch colours on")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if ColourEnabled(ColourAuto, os.Stderr) || !ColourEnabled(ColourOn, &buf) {
+		t.Errorf("NO_COLOR should switch colours off in auto mode only")
+	}
+	f, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if isTerminal(f) {
+		t.Errorf("A file is no terminal")
+	}
+
+	var mode ColourMode
+	for s, want := range map[string]ColourMode{"auto": ColourAuto, "ON": ColourOn, "false": ColourOff, "never": ColourOff} {
+		if err := mode.Set(s); err != nil || mode != want {
+			t.Errorf("Set(%q) = %s, %v", s, mode.String(), err)
+		}
+	}
+	if err := mode.Set("sometimes"); err == nil {
+		t.Errorf("Expected an error for an unknown colour mode")
+	}
+}
+
+func TestTheme(t *testing.T) {
+	defer SetTheme(nil)
+	theme, err := ParseTheme("error=lightRed+bold, fatal=white+bg:red, info=none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetTheme(theme)
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, ALL, true)
+	l.Error("e")
+	l.Fatal("f")
+	l.Info("i")
+	l.Warn("w")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for i, prefix := range []string{"\x1b[91;1mERROR: \x1b[0m", "\x1b[37;41mFATAL: \x1b[0m", " INFO: ", "\x1b[33m WARN: \x1b[0m"} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("Expected prefix %q, got %q", prefix, lines[i])
+		}
+	}
+
+	for _, spec := range []string{"error", "loud=red", "error=purple", "warn=bg:nope"} {
+		if _, err := ParseTheme(spec); err == nil {
+			t.Errorf("Expected an error for theme %q", spec)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// These tests are meant to be run with the race detector: go test -race ./log
+
+const (
+	goroutines = 16
+	iterations = 200
+)
+
+// hammer logs from many goroutines on all five levels, every message names its level.
+func hammer(logf func(level LogLevel, format string, args ...interface{})) {
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				for _, level := range []LogLevel{FATAL, ERROR, WARN, INFO, DEBUG} {
+					logf(level, "level=%s goroutine=%d i=%d", levelName(level), g, i)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+// checkLines verifies that every line carries the level prefix matching its message.
+func checkLines(t *testing.T, out string, expected int) {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != expected {
+		t.Errorf("Expected %d lines, got %d", expected, len(lines))
+	}
+	for _, line := range lines {
+		i := strings.Index(line, "level=")
+		if i < 0 {
+			t.Errorf("Torn line: %q", line)
+			continue
+		}
+		level := strings.Fields(line[i+len("level="):])[0]
+		if !strings.Contains(line[:i], fmt.Sprintf("%5s: ", level)) {
+			t.Errorf("Line has the wrong level prefix: %q", line)
+		}
+	}
+}
+
+func levelFunc(l *Logger) func(level LogLevel, format string, args ...interface{}) {
+	return func(level LogLevel, format string, args ...interface{}) {
+		switch level {
+		case FATAL:
+			l.Fatal(format, args...)
+		case ERROR:
+			l.Error(format, args...)
+		case WARN:
+			l.Warn(format, args...)
+		case INFO:
+			l.Info(format, args...)
+		case DEBUG:
+			l.Debug(format, args...)
+		}
+	}
+}
+
+func convenienceFunc(level LogLevel, format string, args ...interface{}) {
+	switch level {
+	case FATAL:
+		Fatal(format, args...)
+	case ERROR:
+		Error(format, args...)
+	case WARN:
+		Warn(format, args...)
+	case INFO:
+		Info(format, args...)
+	case DEBUG:
+		Debug(format, args...)
+	}
+}
+
+func TestConcurrentLogger(t *testing.T) {
+	for _, coloured := range []bool{false, true} {
+		var buf bytes.Buffer
+		l := NewLoggerFromFile(&buf, ALL, coloured)
+		hammer(levelFunc(l))
+		checkLines(t, buf.String(), goroutines*iterations*5)
+	}
+}
+
+func TestConcurrentChildLoggers(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, ALL, false)
+	hammer(func(level LogLevel, format string, args ...interface{}) {
+		levelFunc(l.With("child", true))(level, format, args...)
+	})
+	checkLines(t, buf.String(), goroutines*iterations*5)
+}
+
+func TestConcurrentStandardLogger(t *testing.T) {
+	old := convenienceLogger.Swap(nil)
+	defer convenienceLogger.Store(old)
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buf)
+	prefix := log.Prefix()
+	hammer(convenienceFunc)
+	if log.Prefix() != prefix {
+		t.Errorf("The prefix of the standard logger was modified: %q", log.Prefix())
+	}
+	checkLines(t, buf.String(), goroutines*iterations*5)
+}
+
+func TestConcurrentConvenienceLogger(t *testing.T) {
+	old := convenienceLogger.Load()
+	defer convenienceLogger.Store(old)
+	var buf1, buf2 bytes.Buffer
+	l1 := NewLoggerFromFile(&buf1, ALL, false)
+	l2 := NewLoggerFromFile(&buf2, ALL, true)
+	l1.SetConvenienceLogger()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			l1.SetConvenienceLogger()
+			l2.SetConvenienceLogger()
+		}
+	}()
+	hammer(convenienceFunc)
+	wg.Wait()
+	checkLines(t, buf1.String()+buf2.String(), goroutines*iterations*5)
+}
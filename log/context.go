@@ -0,0 +1,177 @@
+package log
+
+import (
+	"context"
+	"sync"
+)
+
+// Keys of the well known fields that can be stored in a context.Context.
+const (
+	RequestIDKey = "request_id"
+	JobIDKey     = "job_id"
+	TraceIDKey   = "trace_id"
+	SpanIDKey    = "span_id"
+)
+
+type loggerKey struct{}
+type fieldsKey struct{}
+
+// followingLogger writes into the current convenience logger.
+var followingLogger = &Logger{follow: true}
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []func(ctx context.Context) []Field
+)
+
+// NewContext returns a copy of ctx carrying the logger l. See FromContext.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// ContextWithFields returns a copy of ctx carrying the given key/value pairs in addition to
+// the fields already stored in ctx. They are attached to every message logged using ctx.
+func ContextWithFields(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	old, _ := ctx.Value(fieldsKey{}).([]Field)
+	fields := append(append([]Field(nil), old...), toFields(keysAndValues)...)
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// ContextWithRequestID returns a copy of ctx carrying a request ID.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return ContextWithFields(ctx, RequestIDKey, id)
+}
+
+// ContextWithJobID returns a copy of ctx carrying a job ID.
+func ContextWithJobID(ctx context.Context, id string) context.Context {
+	return ContextWithFields(ctx, JobIDKey, id)
+}
+
+// ContextWithTrace returns a copy of ctx carrying a trace ID and a span ID. They are
+// expected as hex strings, as used by OpenTelemetry.
+func ContextWithTrace(ctx context.Context, traceID, spanID string) context.Context {
+	return ContextWithFields(ctx, TraceIDKey, traceID, SpanIDKey, spanID)
+}
+
+// AddContextExtractor registers a function that extracts fields from a context.Context,
+// e.g. the IDs of the current span of a tracing library. The extracted fields are attached
+// to every message logged with a context.
+func AddContextExtractor(extract func(ctx context.Context) []Field) {
+	extractorsMu.Lock()
+	extractors = append(extractors, extract)
+	extractorsMu.Unlock()
+}
+
+// contextFields returns the fields stored in ctx followed by the extracted ones.
+func contextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]Field)
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+	if len(extractors) == 0 {
+		return fields
+	}
+	fields = append([]Field(nil), fields...)
+	for _, extract := range extractors {
+		fields = append(fields, extract(ctx)...)
+	}
+	return fields
+}
+
+// loggerFrom returns the logger stored in ctx or a logger following the convenience logger.
+func loggerFrom(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey{}).(*Logger); ok {
+			return l
+		}
+	}
+	return followingLogger
+}
+
+// FromContext returns the logger stored in ctx by NewContext, or the convenience logger if
+// there is none. The fields stored in ctx are attached to the returned logger.
+func FromContext(ctx context.Context) *Logger {
+	l := loggerFrom(ctx)
+	if fields := contextFields(ctx); len(fields) > 0 {
+		child := *l
+		child.fields = append(append([]Field(nil), l.fields...), fields...)
+		return &child
+	}
+	return l
+}
+
+func (l *Logger) writelogContext(ctx context.Context, level LogLevel, format string, args []interface{}) {
+	if l.isEnabled(level) {
+		l.output(4, level, format, sprintf(format, args), contextFields(ctx))
+	}
+}
+
+// InfoContext works just as Info, but attaches the fields stored in ctx.
+func (l *Logger) InfoContext(ctx context.Context, format string, args ...interface{}) {
+	l.writelogContext(ctx, INFO, format, args)
+}
+
+// WarnContext works just as Warn, but attaches the fields stored in ctx.
+func (l *Logger) WarnContext(ctx context.Context, format string, args ...interface{}) {
+	l.writelogContext(ctx, WARN, format, args)
+}
+
+// ErrorContext works just as Error, but attaches the fields stored in ctx.
+func (l *Logger) ErrorContext(ctx context.Context, format string, args ...interface{}) {
+	l.writelogContext(ctx, ERROR, format, args)
+}
+
+// FatalContext works just as Fatal, but attaches the fields stored in ctx.
+func (l *Logger) FatalContext(ctx context.Context, format string, args ...interface{}) {
+	l.writelogContext(ctx, FATAL, format, args)
+}
+
+// DebugContext works just as Debug, but attaches the fields stored in ctx.
+func (l *Logger) DebugContext(ctx context.Context, format string, args ...interface{}) {
+	l.writelogContext(ctx, DEBUG, format, args)
+}
+
+// TraceContext works just as Trace, but attaches the fields stored in ctx.
+func (l *Logger) TraceContext(ctx context.Context, format string, args ...interface{}) {
+	l.writelogContext(ctx, TRACE, format, args)
+}
+
+// -----------------------------
+
+// InfoContext works just as Info, but writes into the logger stored in ctx (see
+// FromContext) and attaches the fields stored in ctx.
+func InfoContext(ctx context.Context, format string, args ...interface{}) {
+	loggerFrom(ctx).writelogContext(ctx, INFO, format, args)
+}
+
+// WarnContext works just as Warn, but writes into the logger stored in ctx (see
+// FromContext) and attaches the fields stored in ctx.
+func WarnContext(ctx context.Context, format string, args ...interface{}) {
+	loggerFrom(ctx).writelogContext(ctx, WARN, format, args)
+}
+
+// ErrorContext works just as Error, but writes into the logger stored in ctx (see
+// FromContext) and attaches the fields stored in ctx.
+func ErrorContext(ctx context.Context, format string, args ...interface{}) {
+	loggerFrom(ctx).writelogContext(ctx, ERROR, format, args)
+}
+
+// FatalContext works just as Fatal, but writes into the logger stored in ctx (see
+// FromContext) and attaches the fields stored in ctx.
+func FatalContext(ctx context.Context, format string, args ...interface{}) {
+	loggerFrom(ctx).writelogContext(ctx, FATAL, format, args)
+}
+
+// DebugContext works just as Debug, but writes into the logger stored in ctx (see
+// FromContext) and attaches the fields stored in ctx.
+func DebugContext(ctx context.Context, format string, args ...interface{}) {
+	loggerFrom(ctx).writelogContext(ctx, DEBUG, format, args)
+}
+
+// TraceContext works just as Trace, but writes into the logger stored in ctx (see
+// FromContext) and attaches the fields stored in ctx.
+func TraceContext(ctx context.Context, format string, args ...interface{}) {
+	loggerFrom(ctx).writelogContext(ctx, TRACE, format, args)
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestContextLogging(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, ALL, false).With("tool", "grid")
+	ctx := NewContext(context.Background(), l)
+	ctx = ContextWithRequestID(ctx, "r-17")
+	ctx = ContextWithTrace(ctx, "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+
+	WarnContext(ctx, "slow %s", "request")
+	FromContext(ctx).Infow("done", "ms", 12)
+	slog.New(NewSlogHandler(l)).InfoContext(ctx, "via slog")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d: %s", len(lines), buf.String())
+	}
+	ids := "request_id=r-17 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7"
+	if !strings.HasSuffix(lines[0], "slow request tool=grid "+ids) || !strings.Contains(lines[0], "context_test.go") {
+		t.Errorf("Unexpected line: %s", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "done tool=grid "+ids+" ms=12") {
+		t.Errorf("Unexpected line: %s", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], "via slog tool=grid "+ids) {
+		t.Errorf("Unexpected line: %s", lines[2])
+	}
+}
+
+func TestContextExtractor(t *testing.T) {
+	defer func() { extractors = nil }()
+	type spanKey struct{}
+	AddContextExtractor(func(ctx context.Context) []Field {
+		if span, ok := ctx.Value(spanKey{}).(string); ok {
+			return []Field{F(SpanIDKey, span)}
+		}
+		return nil
+	})
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, ALL, false)
+	l.ErrorContext(context.WithValue(context.Background(), spanKey{}, "abc"), "failed")
+	if !strings.HasSuffix(strings.TrimSpace(buf.String()), "failed span_id=abc") {
+		t.Errorf("Unexpected output: %s", buf.String())
+	}
+}
@@ -0,0 +1,213 @@
+package log
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// BufferedKey is the field marking the messages written from the crash buffer, see
+// Logger.SetCrashBuffer.
+const BufferedKey = "buffered"
+
+// crashBuffer is a ring of the most recent records of a Logger. It is shared between a
+// Logger and its children.
+type crashBuffer struct {
+	size    atomic.Int32
+	mu      sync.Mutex
+	records []crashEntry
+	next    int
+}
+
+// crashEntry is a kept Record and the destinations that received it when it was logged.
+// out is the loggers own stream, or its slog.Handler.
+type crashEntry struct {
+	r     *Record
+	out   bool
+	sinks []*Sink
+}
+
+// received reports whether the Sink received the Record of the entry.
+func (e crashEntry) received(s *Sink) bool {
+	for _, r := range e.sinks {
+		if r == s {
+			return true
+		}
+	}
+	return false
+}
+
+// enabled reports whether the crash buffer keeps records.
+func (cb *crashBuffer) enabled() bool {
+	return cb != nil && cb.size.Load() > 0
+}
+
+func (cb *crashBuffer) resize(size int) {
+	if size < 0 {
+		size = 0
+	}
+	cb.mu.Lock()
+	cb.size.Store(int32(size))
+	cb.records, cb.next = nil, 0
+	cb.mu.Unlock()
+}
+
+// add keeps an entry, replacing the oldest one if the buffer is full.
+func (cb *crashBuffer) add(e crashEntry) {
+	if !cb.enabled() {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	size := int(cb.size.Load())
+	if len(cb.records) < size {
+		cb.records = append(cb.records, e)
+		return
+	}
+	if size == 0 {
+		return
+	}
+	cb.records[cb.next] = e
+	cb.next = (cb.next + 1) % size
+}
+
+// take returns the kept entries, oldest first, and empties the buffer.
+func (cb *crashBuffer) take() []crashEntry {
+	if !cb.enabled() {
+		return nil
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	records := append(cb.records[cb.next:len(cb.records):len(cb.records)], cb.records[:cb.next]...)
+	cb.records, cb.next = nil, 0
+	return records
+}
+
+// SetCrashBuffer lets the Logger keep the last size messages of all levels, regardless of
+// its LogLevel and the levels of its sinks. When an ERROR or FATAL message is logged, the
+// kept messages that were filtered by a destination are written to it right before the
+// error, marked by the field BufferedKey. So a program running at WARN shows the context
+// that led to an error. A size of 0 switches the crash buffer off, it is off by default.
+// Every message is formatted while the crash buffer is on, so it costs some performance.
+func (l *Logger) SetCrashBuffer(size int) {
+	l.target().crash.resize(size)
+}
+
+// keep adds a Record to the crash buffer together with the destinations its level passes
+// at this moment, so a later change of the levels does not write it twice.
+func (l *Logger) keep(r *Record) {
+	if !l.crash.enabled() {
+		return
+	}
+	out, sinks := l.receivers(r)
+	l.crash.add(crashEntry{r: r, out: out, sinks: sinks})
+}
+
+// receivers returns whether the level of a Record passes the loggers own stream, or its
+// slog.Handler, and the sinks it passes.
+func (l *Logger) receivers(r *Record) (out bool, sinks []*Sink) {
+	active, sinkLimit := l.limits(r.Name)
+	if l.handler != nil {
+		return levelEnabled(sinkLimit, r.Level) && l.handler.Enabled(context.Background(), slogLevel(r.Level)), nil
+	}
+	for _, s := range l.sinks.list() {
+		if s.isEnabled(r.Level, active, sinkLimit) {
+			sinks = append(sinks, s)
+		}
+	}
+	return levelEnabled(active, r.Level), sinks
+}
+
+// dumpCrashBuffer writes the kept records to every destination that receives the trigger,
+// skipping those records the destination received when they were logged.
+func (l *Logger) dumpCrashBuffer(trigger *Record) {
+	entries := l.crash.take()
+	if len(entries) == 0 {
+		return
+	}
+	for i, e := range entries {
+		c := *e.r
+		c.Fields = append(e.r.Fields[:len(e.r.Fields):len(e.r.Fields)], F(BufferedKey, true))
+		entries[i].r = &c
+	}
+	out, sinks := l.receivers(trigger)
+	if out {
+		for _, e := range entries {
+			switch {
+			case e.out:
+			case l.handler != nil:
+				l.forwardToHandler(e.r)
+			default:
+				l.writeOut(e.r)
+			}
+		}
+	}
+	for _, s := range sinks {
+		for _, e := range entries {
+			if !e.received(s) {
+				s.writeRecord(e.r)
+			}
+		}
+	}
+}
+
+// LogPanic logs a panic as FATAL message, including the stack trace in the field "stack",
+// flushes the logger and panics again. The FATAL message writes the crash buffer, see
+// SetCrashBuffer. It has to be deferred directly:
+//
+//	defer logger.LogPanic()
+func (l *Logger) LogPanic() {
+	if p := recover(); p != nil {
+		l.logPanic(p)
+		panic(p)
+	}
+}
+
+// logPanic logs a recovered panic, attributed to the function that panicked.
+func (l *Logger) logPanic(p interface{}) {
+	l.outputAt(panicSite(), FATAL, "panic: %v", sprintf("panic: %v", []interface{}{p}),
+		[]Field{F("stack", strings.TrimSpace(string(debug.Stack())))})
+	l.Flush()
+}
+
+// panicSite returns the program counter of the function that panicked, the first one
+// below the panic that is not part of the runtime.
+func panicSite() uintptr {
+	var pcs [32]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		if panicking && !strings.HasPrefix(frame.Function, "runtime.") {
+			return frame.PC + 1
+		}
+		if frame.Function == "runtime.gopanic" {
+			panicking = true
+		}
+		if !more {
+			return 0
+		}
+	}
+}
+
+// -----------------------------
+
+// SetCrashBuffer sets the size of the crash buffer of the convenience logger, see
+// Logger.SetCrashBuffer.
+func SetCrashBuffer(size int) {
+	logger().SetCrashBuffer(size)
+}
+
+// LogPanic logs a panic with the convenience logger and panics again, see Logger.LogPanic.
+// It has to be deferred directly:
+//
+//	defer log.LogPanic()
+func LogPanic() {
+	if p := recover(); p != nil {
+		logger().logPanic(p)
+		panic(p)
+	}
+}
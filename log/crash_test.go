@@ -0,0 +1,115 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCrashBuffer(t *testing.T) {
+	var buf, sinkbuf bytes.Buffer
+	l := NewLoggerWithEncoder(&buf, WARN, false, &LogfmtEncoder{})
+	l.AddSink(NewSink(&sinkbuf, INFO, false, &LogfmtEncoder{}))
+	l.SetCrashBuffer(3)
+
+	l.Trace("dropped from the ring")
+	l.Debug("reading a.csv")
+	l.Infow("parsed", "row", 7)
+	l.Warn("row 8 is empty")
+	l.Error("cannot parse row 9")
+	l.Error("cannot parse row 10")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{`msg="row 8 is empty"`, `msg="reading a.csv" buffered=true`,
+		`msg=parsed row=7 buffered=true`, `msg="cannot parse row 9"`, `msg="cannot parse row 10"`}
+	if len(lines) != len(want) {
+		t.Fatalf("Expected %d lines, got:\n%s", len(want), buf.String())
+	}
+	for i, w := range want {
+		if !strings.HasSuffix(lines[i], w) {
+			t.Errorf("Line %d: expected suffix %q, got %q", i, w, lines[i])
+		}
+	}
+	if strings.Contains(buf.String(), "dropped") {
+		t.Errorf("Ring kept more than 3 messages:\n%s", buf.String())
+	}
+
+	out := sinkbuf.String()
+	if strings.Count(out, "buffered") != 1 || !strings.Contains(out, `msg="reading a.csv" buffered=true`) {
+		t.Errorf("Sink should only receive the messages it filtered:\n%s", out)
+	}
+}
+
+func TestCrashBufferLevelChange(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerWithEncoder(&buf, ALL, false, &LogfmtEncoder{})
+	l.SetCrashBuffer(3)
+	l.Debug("written")
+	l.SetLevel(WARN)
+	l.Debug("filtered")
+	l.Error("failed")
+	if out := buf.String(); strings.Count(out, "written") != 1 || !strings.Contains(out, `msg=filtered buffered=true`) {
+		t.Errorf("Unexpected output:\n%s", out)
+	}
+}
+
+func TestCrashBufferOff(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, WARN, false)
+	l.SetCrashBuffer(2)
+	l.SetCrashBuffer(0)
+	l.Debug("not kept")
+	l.Error("failed")
+	if strings.Contains(buf.String(), "not kept") {
+		t.Errorf("Crash buffer still active:\n%s", buf.String())
+	}
+}
+
+func TestLogPanic(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerWithEncoder(&buf, ERROR, false, &LogfmtEncoder{})
+	l.SetCrashBuffer(10)
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("Expected the panic to be raised again, got %v", p)
+		}
+		out := buf.String()
+		if !strings.Contains(out, `msg="about to fail" buffered=true`) {
+			t.Errorf("Crash buffer not written:\n%s", out)
+		}
+		if !strings.Contains(out, `level=fatal`) || !strings.Contains(out, `msg="panic: boom"`) ||
+			!strings.Contains(out, "crash_test.go") || !strings.Contains(out, "stack=") {
+			t.Errorf("Unexpected panic message:\n%s", out)
+		}
+	}()
+	func() {
+		defer l.LogPanic()
+		l.Info("about to fail")
+		panic("boom")
+	}()
+}
+
+func TestCrashBufferDatagrams(t *testing.T) {
+	pc, addr := listen(t, "unixgram")
+	sink, err := NewJournalSink(addr, WARN, "tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, ALL, false)
+	l.AddSink(sink)
+	l.SetCrashBuffer(5)
+
+	l.Info("one")
+	l.Info("two")
+	l.Error("failed")
+	for _, want := range []string{"MESSAGE=one\n", "MESSAGE=two\n", "MESSAGE=failed\n"} {
+		if msg := receive(t, pc); strings.Count(msg, "MESSAGE=") != 1 || !strings.HasPrefix(msg, want) {
+			t.Errorf("Expected a datagram starting with %q, got %q", want, msg)
+		}
+	}
+	if strings.Contains(buf.String(), "buffered") {
+		t.Errorf("Records written to the stream again:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,159 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An Encoder formats a Record into a single line of output, including the trailing
+// newline. The coloured flag tells the Encoder whether it may use terminal colours.
+type Encoder interface {
+	Encode(buf *bytes.Buffer, r *Record, coloured bool)
+}
+
+var defaultEncoder Encoder = &TextEncoder{Flags: loggerflags}
+
+// EncoderFor returns the Encoder for one of the format names "text", "json" or "logfmt".
+// The name is case insensitive.
+func EncoderFor(format string) (Encoder, error) {
+	switch strings.ToLower(format) {
+	case "", "text":
+		return &TextEncoder{Flags: loggerflags}, nil
+	case "json":
+		return &JSONEncoder{}, nil
+	case "logfmt":
+		return &LogfmtEncoder{}, nil
+	}
+	return nil, fmt.Errorf("unknown log format '%s'", format)
+}
+
+// TextEncoder writes the classic 'LEVEL: date time file:line: msg key=value' line. The
+// message is preceded by 'name: ' for named loggers.
+// Flags take the same values as the flags of the standard logger (package log),
+// Lmsgprefix is not supported.
+type TextEncoder struct {
+	Flags int
+}
+
+// Encode implements Encoder.
+func (e *TextEncoder) Encode(buf *bytes.Buffer, r *Record, coloured bool) {
+	prefix := fmt.Sprintf("%5s: ", levelName(r.Level))
+	if coloured {
+		prefix = colorize(r.Level, prefix)
+	}
+	buf.WriteString(prefix)
+	e.writeHeader(buf, r)
+	if r.Name != "" {
+		buf.WriteString(r.Name)
+		buf.WriteString(": ")
+	}
+	buf.WriteString(r.Message)
+	buf.WriteString(formatFields(r.Fields))
+	if buf.Len() == 0 || buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+}
+
+// writeHeader mimics the header written by the standard logger.
+func (e *TextEncoder) writeHeader(buf *bytes.Buffer, r *Record) {
+	t := r.Time
+	if e.Flags&log.LUTC != 0 {
+		t = t.UTC()
+	}
+	if e.Flags&log.Ldate != 0 {
+		buf.WriteString(t.Format("2006/01/02 "))
+	}
+	if e.Flags&(log.Ltime|log.Lmicroseconds) != 0 {
+		if e.Flags&log.Lmicroseconds != 0 {
+			buf.WriteString(t.Format("15:04:05.000000 "))
+		} else {
+			buf.WriteString(t.Format("15:04:05 "))
+		}
+	}
+	if e.Flags&(log.Lshortfile|log.Llongfile) != 0 {
+		file := r.File
+		if e.Flags&log.Lshortfile != 0 {
+			file = shortFile(file)
+		}
+		buf.WriteString(file)
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Itoa(r.Line))
+		buf.WriteString(": ")
+	}
+}
+
+func shortFile(file string) string {
+	if i := strings.LastIndexByte(file, '/'); i >= 0 {
+		return file[i+1:]
+	}
+	return file
+}
+
+// JSONEncoder writes every Record as a single JSON object with the keys time, level,
+// file, line, logger (for named loggers only) and msg followed by the fields of the Record.
+type JSONEncoder struct{}
+
+// Encode implements Encoder.
+func (e *JSONEncoder) Encode(buf *bytes.Buffer, r *Record, coloured bool) {
+	buf.WriteString(`{"time":`)
+	writeJSONValue(buf, r.Time.UTC().Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSONValue(buf, levelName(r.Level))
+	buf.WriteString(`,"file":`)
+	writeJSONValue(buf, r.File)
+	buf.WriteString(`,"line":`)
+	buf.WriteString(strconv.Itoa(r.Line))
+	if r.Name != "" {
+		buf.WriteString(`,"logger":`)
+		writeJSONValue(buf, r.Name)
+	}
+	buf.WriteString(`,"msg":`)
+	writeJSONValue(buf, r.Message)
+	for _, f := range r.Fields {
+		buf.WriteByte(',')
+		writeJSONValue(buf, f.Key)
+		buf.WriteByte(':')
+		writeJSONValue(buf, f.Value)
+	}
+	buf.WriteString("}\n")
+}
+
+// writeJSONValue marshals v. Errors are written as their message, values that can not be
+// marshalled are written as string using fmt.Sprint.
+func writeJSONValue(buf *bytes.Buffer, v interface{}) {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(b)
+}
+
+// LogfmtEncoder writes every Record as a logfmt line
+// 'time=... level=... caller=file:line msg=... key=value'.
+type LogfmtEncoder struct{}
+
+// Encode implements Encoder.
+func (e *LogfmtEncoder) Encode(buf *bytes.Buffer, r *Record, coloured bool) {
+	buf.WriteString("time=")
+	buf.WriteString(r.Time.UTC().Format(time.RFC3339Nano))
+	buf.WriteString(" level=")
+	buf.WriteString(strings.ToLower(levelName(r.Level)))
+	buf.WriteString(" caller=")
+	buf.WriteString(quoteIfNeeded(shortFile(r.File) + ":" + strconv.Itoa(r.Line)))
+	if r.Name != "" {
+		buf.WriteString(" logger=")
+		buf.WriteString(quoteIfNeeded(r.Name))
+	}
+	buf.WriteString(" msg=")
+	buf.WriteString(quoteIfNeeded(r.Message))
+	buf.WriteString(formatFields(r.Fields))
+	buf.WriteByte('\n')
+}
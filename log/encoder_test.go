@@ -0,0 +1,49 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncoderFor(t *testing.T) {
+	for _, format := range []string{"", "text", "JSON", "logfmt"} {
+		if _, err := EncoderFor(format); err != nil {
+			t.Errorf("Format '%s' should be known: %s", format, err)
+		}
+	}
+	if _, err := EncoderFor("xml"); err == nil {
+		t.Error("Format 'xml' should not be known")
+	}
+}
+
+func TestJSONEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerWithEncoder(&buf, ALL, true, &JSONEncoder{})
+	l.With("job", 7).Warnw("disk \"full\"", "err", errors.New("no space"), "free", 0.5)
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("Output is no valid JSON: %s\n%s", err, buf.String())
+	}
+	expected := map[string]interface{}{"level": "WARN", "msg": "disk \"full\"", "job": 7.0, "err": "no space", "free": 0.5}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Errorf("Key '%s' should be %v, but is %v", k, v, m[k])
+		}
+	}
+	if !strings.HasSuffix(m["file"].(string), "encoder_test.go") {
+		t.Errorf("Wrong file: %v", m["file"])
+	}
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerWithEncoder(&buf, ALL, true, &LogfmtEncoder{})
+	l.Error("failed after %d tries", 3)
+	out := buf.String()
+	if !strings.Contains(out, ` level=error caller=encoder_test.go:`) || !strings.HasSuffix(out, ` msg="failed after 3 tries"`+"\n") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+}
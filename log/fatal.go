@@ -0,0 +1,129 @@
+package log
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	fatalExitCode atomic.Int32
+	exitHooksMu   sync.Mutex
+	exitHooks     []func()
+	exiting       atomic.Bool
+	// exitFunc terminates the program, it is replaced in tests.
+	exitFunc = os.Exit
+)
+
+func init() {
+	fatalExitCode.Store(1)
+}
+
+// SetFatalExitCode sets the exit code used by FatalExit. It is 1 by default.
+func SetFatalExitCode(code int) {
+	fatalExitCode.Store(int32(code))
+}
+
+// FatalExitCode returns the exit code used by FatalExit.
+func FatalExitCode() int {
+	return int(fatalExitCode.Load())
+}
+
+// AddExitHook registers a function that is run by FatalExit before the program terminates,
+// e.g. the clean up of a CommonConfig. The hooks are run in the order of registration.
+func AddExitHook(hook func()) {
+	exitHooksMu.Lock()
+	exitHooks = append(exitHooks, hook)
+	exitHooksMu.Unlock()
+}
+
+// Flusher is implemented by destinations that buffer their output.
+type Flusher interface {
+	Flush() error
+}
+
+// syncer is implemented by os.File.
+type syncer interface {
+	Sync() error
+}
+
+// flushWriter flushes a destination, if it buffers its output. Files are synced, except
+// for the console.
+func flushWriter(w io.Writer) error {
+	if f, ok := w.(Flusher); ok {
+		return f.Flush()
+	}
+	if f, ok := w.(syncer); ok && !isConsole(w) {
+		return f.Sync()
+	}
+	return nil
+}
+
+// Flush writes a pending 'last message repeated' message, waits for the asynchronous hooks
+// and flushes the stream of the logger and all of its sinks.
+func (l *Logger) Flush() error {
+	t := l.target()
+	if repeated := t.dedup.flush(); repeated != nil {
+		t.emit(repeated)
+	}
+	t.hooks.wait()
+	err := flushWriter(t.out)
+	for _, s := range t.sinks.list() {
+		if serr := flushWriter(s.Out); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// exit flushes the logger, runs the exit hooks and terminates the program. Hooks calling
+// FatalExit again do not start another round of hooks.
+func (l *Logger) exit() {
+	l.Flush()
+	if exiting.CompareAndSwap(false, true) {
+		exitHooksMu.Lock()
+		hooks := append([]func(){}, exitHooks...)
+		exitHooksMu.Unlock()
+		for _, hook := range hooks {
+			hook()
+		}
+	}
+	exitFunc(FatalExitCode())
+}
+
+// FatalExit works just as Fatal, but terminates the program afterwards. The logger and its
+// sinks are flushed and the exit hooks are run (see AddExitHook), then the program exits with
+// the code set by SetFatalExitCode.
+func (l *Logger) FatalExit(format string, args ...interface{}) {
+	l.writelog(FATAL, format, args...)
+	l.exit()
+}
+
+// FatalPanic works just as Fatal, but panics with the message afterwards. The logger and its
+// sinks are flushed before. Library code should prefer it over FatalExit.
+func (l *Logger) FatalPanic(format string, args ...interface{}) {
+	msg := sprintf(format, args)
+	l.writelog(FATAL, "%s", msg)
+	l.Flush()
+	panic(msg)
+}
+
+// -----------------------------
+
+// FatalExit works just as Fatal, but terminates the program afterwards, see Logger.FatalExit.
+func FatalExit(format string, args ...interface{}) {
+	l := logger()
+	l.writelog(FATAL, format, args...)
+	l.exit()
+}
+
+// FatalPanic works just as Fatal, but panics with the message afterwards, see
+// Logger.FatalPanic.
+func FatalPanic(format string, args ...interface{}) {
+	l := logger()
+	msg := sprintf(format, args)
+	l.writelog(FATAL, "%s", msg)
+	l.Flush()
+	panic(msg)
+}
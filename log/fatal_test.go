@@ -0,0 +1,68 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type flushRecorder struct {
+	bytes.Buffer
+	flushed int
+}
+
+func (f *flushRecorder) Flush() error {
+	f.flushed++
+	return nil
+}
+
+func TestFatalExit(t *testing.T) {
+	code := -1
+	osExit := exitFunc
+	exitFunc = func(c int) { code = c }
+	defer func() {
+		exitFunc = osExit
+		exiting.Store(false)
+		exitHooks = nil
+		SetFatalExitCode(1)
+	}()
+	var out, sink flushRecorder
+	l := NewLoggerFromFile(&out, ERROR, false)
+	l.AddSink(NewSink(&sink, ALL, false, nil))
+	var calls []string
+	AddExitHook(func() { calls = append(calls, "first") })
+	AddExitHook(func() {
+		calls = append(calls, "second")
+		l.FatalExit("nested")
+	})
+	SetFatalExitCode(3)
+
+	l.FatalExit("going down: %d", 42)
+	if !strings.Contains(out.String(), "FATAL: ") || !strings.Contains(out.String(), "going down: 42") {
+		t.Errorf("Unexpected output: %s", out.String())
+	}
+	if out.flushed == 0 || sink.flushed == 0 {
+		t.Errorf("Logger or sink was not flushed: %d %d", out.flushed, sink.flushed)
+	}
+	if strings.Join(calls, ",") != "first,second" {
+		t.Errorf("Exit hooks were not run once in order: %v", calls)
+	}
+	if code != 3 {
+		t.Errorf("Exit code should be 3, but is %d", code)
+	}
+}
+
+func TestFatalPanic(t *testing.T) {
+	var out flushRecorder
+	l := NewLoggerFromFile(&out, ALL, false)
+	defer func() {
+		r := recover()
+		if r != "broken 7" {
+			t.Errorf("Unexpected panic value: %v", r)
+		}
+		if !strings.Contains(out.String(), "FATAL: ") || out.flushed != 1 {
+			t.Errorf("Message not written and flushed: %s", out.String())
+		}
+	}()
+	l.FatalPanic("broken %d", 7)
+}
@@ -0,0 +1,165 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// badKey is used as key for a dangling value in a key/value list.
+const badKey = "!BADKEY"
+
+// A Field is a single key/value pair that is attached to a structured log message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F creates a Field. It is a short cut for Field{key, value}.
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// toFields converts a list of alternating keys and values into Fields. Elements that
+// already are a Field are taken as they are. A key that is not a string is converted
+// using fmt.Sprint, a value without a key is stored under the key "!BADKEY".
+func toFields(keysAndValues []interface{}) []Field {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+	fields := make([]Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i++ {
+		switch k := keysAndValues[i].(type) {
+		case Field:
+			fields = append(fields, k)
+		case string:
+			if i+1 < len(keysAndValues) {
+				fields = append(fields, Field{Key: k, Value: keysAndValues[i+1]})
+				i++
+			} else {
+				fields = append(fields, Field{Key: badKey, Value: k})
+			}
+		default:
+			if i+1 < len(keysAndValues) {
+				fields = append(fields, Field{Key: fmt.Sprint(k), Value: keysAndValues[i+1]})
+				i++
+			} else {
+				fields = append(fields, Field{Key: badKey, Value: k})
+			}
+		}
+	}
+	return fields
+}
+
+// formatFields renders fields as " key=value key=value". Values containing blanks,
+// quotes or '=' are quoted.
+func formatFields(fields []Field) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		b.WriteString(quoteIfNeeded(fmt.Sprint(f.Value)))
+	}
+	return b.String()
+}
+
+func quoteIfNeeded(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// With returns a child logger that writes to the same stream with the same settings,
+// but adds the given key/value pairs to every message. The fields of the parent logger
+// are carried forward.
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	child := *l
+	child.fields = append(append([]Field(nil), l.fields...), toFields(keysAndValues)...)
+	return &child
+}
+
+func (l *Logger) writelogw(level LogLevel, msg string, keysAndValues []interface{}) {
+	if l.isEnabled(level) {
+		l.output(4, level, msg, msg, toFields(keysAndValues))
+	}
+}
+
+// Infow writes msg followed by the given key/value pairs into the loggers stream.
+// The message is only printed if ActiveLogLevel is set higher or equal to 'Info'
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	l.writelogw(INFO, msg, keysAndValues)
+}
+
+// Warnw writes msg followed by the given key/value pairs into the loggers stream.
+// The message is only printed if ActiveLogLevel is set higher or equal to 'Warn'
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.writelogw(WARN, msg, keysAndValues)
+}
+
+// Errorw writes msg followed by the given key/value pairs into the loggers stream.
+// The message is only printed if ActiveLogLevel is set higher or equal to 'Error'
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.writelogw(ERROR, msg, keysAndValues)
+}
+
+// Fatalw writes msg followed by the given key/value pairs into the loggers stream.
+// The message is only printed if ActiveLogLevel is set higher or equal to 'Fatal'
+func (l *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	l.writelogw(FATAL, msg, keysAndValues)
+}
+
+// Debugw writes msg followed by the given key/value pairs into the loggers stream.
+// The message is only printed if ActiveLogLevel is set higher or equal to 'Debug', or
+// if the developer mode is switched on (see SetDeveloperMode).
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.writelogw(DEBUG, msg, keysAndValues)
+}
+
+// Tracew writes msg followed by the given key/value pairs into the loggers stream.
+// The message is only printed if ActiveLogLevel is set higher or equal to 'Trace'
+func (l *Logger) Tracew(msg string, keysAndValues ...interface{}) {
+	l.writelogw(TRACE, msg, keysAndValues)
+}
+
+// -----------------------------
+
+// Infow writes msg followed by the given key/value pairs into the Convenience loggers stream.
+// It uses the standard logger (package log) if the Convenience logger is unset.
+func Infow(msg string, keysAndValues ...interface{}) {
+	logger().writelogw(INFO, msg, keysAndValues)
+}
+
+// Warnw writes msg followed by the given key/value pairs into the Convenience loggers stream.
+// It uses the standard logger (package log) if the Convenience logger is unset.
+func Warnw(msg string, keysAndValues ...interface{}) {
+	logger().writelogw(WARN, msg, keysAndValues)
+}
+
+// Errorw writes msg followed by the given key/value pairs into the Convenience loggers stream.
+// It uses the standard logger (package log) if the Convenience logger is unset.
+func Errorw(msg string, keysAndValues ...interface{}) {
+	logger().writelogw(ERROR, msg, keysAndValues)
+}
+
+// Fatalw writes msg followed by the given key/value pairs into the Convenience loggers stream.
+// It uses the standard logger (package log) if the Convenience logger is unset.
+func Fatalw(msg string, keysAndValues ...interface{}) {
+	logger().writelogw(FATAL, msg, keysAndValues)
+}
+
+// Debugw writes msg followed by the given key/value pairs into the Convenience loggers stream.
+// It uses the standard logger (package log) if the Convenience logger is unset.
+func Debugw(msg string, keysAndValues ...interface{}) {
+	logger().writelogw(DEBUG, msg, keysAndValues)
+}
+
+// Tracew writes msg followed by the given key/value pairs into the Convenience loggers stream.
+// It uses the standard logger (package log) if the Convenience logger is unset.
+func Tracew(msg string, keysAndValues ...interface{}) {
+	logger().writelogw(TRACE, msg, keysAndValues)
+}
@@ -0,0 +1,54 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestToFields(t *testing.T) {
+	fields := toFields([]interface{}{"user", 42, F("latency", "3ms"), 7, "x", "dangling"})
+	expected := []Field{{"user", 42}, {"latency", "3ms"}, {"7", "x"}, {badKey, "dangling"}}
+	if len(fields) != len(expected) {
+		t.Fatalf("Expected %d fields, got %d: %v", len(expected), len(fields), fields)
+	}
+	for i := range expected {
+		if fields[i] != expected[i] {
+			t.Errorf("Field %d should be %v, but is %v", i, expected[i], fields[i])
+		}
+	}
+}
+
+func TestInfow(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, INFO, false)
+	l.Infow("request done", "user", "bob", "path", "/a b")
+	out := buf.String()
+	if !strings.Contains(out, `request done user=bob path="/a b"`) {
+		t.Errorf("Unexpected output: %s", out)
+	}
+	if !strings.Contains(out, "fields_test.go") {
+		t.Errorf("Output should name the calling file: %s", out)
+	}
+	buf.Reset()
+	l.ActiveLoglevel = ERROR
+	l.Warnw("filtered")
+	if strings.Contains(buf.String(), "filtered") {
+		t.Errorf("Message above the active log level was written: %s", buf.String())
+	}
+}
+
+func TestWith(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, ALL, false)
+	child := l.With("job", 7).With("step", "load")
+	child.Info("starting %d", 1)
+	if !strings.Contains(buf.String(), "starting 1 job=7 step=load") {
+		t.Errorf("Unexpected output: %s", buf.String())
+	}
+	buf.Reset()
+	l.Info("parent")
+	if strings.Contains(buf.String(), "job=7") {
+		t.Errorf("Fields of the child leaked into the parent: %s", buf.String())
+	}
+}
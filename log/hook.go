@@ -0,0 +1,175 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// A Hook is called for every written Record of the levels it was added for, e.g. to count
+// errors, write a crash report or notify a webhook. A Hook must not modify the Record.
+type Hook interface {
+	Fire(r *Record) error
+}
+
+// HookFunc adapts a function to the Hook interface.
+type HookFunc func(r *Record) error
+
+// Fire implements Hook.
+func (f HookFunc) Fire(r *Record) error {
+	return f(r)
+}
+
+// hookErrors receives the errors and panics of hooks. It is replaced in tests.
+var hookErrors io.Writer = os.Stderr
+
+type hookEntry struct {
+	hook  Hook
+	level LogLevel
+	// queue is nil for synchronous hooks.
+	queue   chan *Record
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending int
+	closed  bool
+}
+
+// hookSet holds the hooks of a Logger. It is shared between a Logger and its children.
+type hookSet struct {
+	mu      sync.RWMutex
+	hooks   []*hookEntry
+	dropped atomic.Uint64
+}
+
+// AddHook adds a Hook that is called synchronously for every Record of the given level or
+// more severe, e.g. ERROR for ERROR and FATAL messages. The Hook is called after the Record
+// was written. Errors and panics of the Hook are reported to STDERR, they never stop the
+// logging.
+func (l *Logger) AddHook(h Hook, level LogLevel) {
+	l.target().hooks.add(&hookEntry{hook: h, level: level})
+}
+
+// AddAsyncHook works like AddHook, but the Hook is called in a background goroutine. Up to
+// size Records are queued, further Records are dropped until the Hook caught up (see
+// HooksDropped). Flush waits until all queued Records were handed to the Hook. Close stops
+// the goroutine, Records written afterwards are dropped.
+func (l *Logger) AddAsyncHook(h Hook, level LogLevel, size int) {
+	if size < 1 {
+		size = 1
+	}
+	e := &hookEntry{hook: h, level: level, queue: make(chan *Record, size)}
+	e.cond = sync.NewCond(&e.mu)
+	go e.run()
+	l.target().hooks.add(e)
+}
+
+// HooksDropped returns the number of Records not handed to asynchronous hooks, because
+// their queue was full.
+func (l *Logger) HooksDropped() uint64 {
+	return l.target().hooks.dropped.Load()
+}
+
+func (hs *hookSet) add(e *hookEntry) {
+	hs.mu.Lock()
+	hs.hooks = append(hs.hooks, e)
+	hs.mu.Unlock()
+}
+
+func (hs *hookSet) list() []*hookEntry {
+	if hs == nil {
+		return nil
+	}
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	return hs.hooks
+}
+
+// enabled reports whether a hook is interested in messages of the level.
+func (hs *hookSet) enabled(level LogLevel) bool {
+	for _, e := range hs.list() {
+		if e.level >= level {
+			return true
+		}
+	}
+	return false
+}
+
+// fire hands a Record to all hooks interested in its level.
+func (hs *hookSet) fire(r *Record) {
+	for _, e := range hs.list() {
+		if e.level < r.Level {
+			continue
+		}
+		if e.queue == nil {
+			e.call(r)
+			continue
+		}
+		e.mu.Lock()
+		if e.closed {
+			hs.dropped.Add(1)
+			e.mu.Unlock()
+			continue
+		}
+		select {
+		case e.queue <- r:
+			e.pending++
+		default:
+			hs.dropped.Add(1)
+		}
+		e.mu.Unlock()
+	}
+}
+
+// wait blocks until the asynchronous hooks handled all queued Records.
+func (hs *hookSet) wait() {
+	for _, e := range hs.list() {
+		if e.queue == nil {
+			continue
+		}
+		e.mu.Lock()
+		for e.pending > 0 {
+			e.cond.Wait()
+		}
+		e.mu.Unlock()
+	}
+}
+
+// close waits for the asynchronous hooks and stops their goroutines.
+func (hs *hookSet) close() {
+	hs.wait()
+	for _, e := range hs.list() {
+		if e.queue == nil {
+			continue
+		}
+		e.mu.Lock()
+		if !e.closed {
+			e.closed = true
+			close(e.queue)
+		}
+		e.mu.Unlock()
+	}
+}
+
+func (e *hookEntry) run() {
+	for r := range e.queue {
+		e.call(r)
+		e.mu.Lock()
+		e.pending--
+		e.cond.Broadcast()
+		e.mu.Unlock()
+	}
+}
+
+// call fires the hook, isolating the logger from its errors and panics.
+func (e *hookEntry) call(r *Record) {
+	defer func() {
+		if p := recover(); p != nil {
+			fmt.Fprintf(hookErrors, "log: hook %T panicked: %v\n", e.hook, p)
+		}
+	}()
+	if err := e.hook.Fire(r); err != nil {
+		fmt.Fprintf(hookErrors, "log: hook %T failed: %v\n", e.hook, err)
+	}
+}
@@ -0,0 +1,105 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHooks(t *testing.T) {
+	var errbuf bytes.Buffer
+	hookErrors = &errbuf
+	defer func() { hookErrors = os.Stderr }()
+
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, OFF, false)
+	var mu sync.Mutex
+	var synced, async []string
+	l.AddHook(HookFunc(func(r *Record) error {
+		synced = append(synced, r.Message)
+		return nil
+	}), ERROR)
+	l.AddAsyncHook(HookFunc(func(r *Record) error {
+		mu.Lock()
+		async = append(async, levelName(r.Level)+" "+r.Message)
+		mu.Unlock()
+		return nil
+	}), WARN, 10)
+	l.AddHook(HookFunc(func(r *Record) error { panic("broken hook") }), FATAL)
+	l.AddHook(HookFunc(func(r *Record) error { return errors.New("webhook down") }), ERROR)
+
+	l.Info("ignored")
+	l.Warn("low disk")
+	l.With("file", "a.csv").Error("cannot parse")
+	l.Fatal("giving up")
+	l.Flush()
+
+	if buf.Len() != 0 {
+		t.Errorf("Logger level OFF wrote: %s", buf.String())
+	}
+	if strings.Join(synced, "|") != "cannot parse|giving up" {
+		t.Errorf("Unexpected sync hook calls: %v", synced)
+	}
+	mu.Lock()
+	if strings.Join(async, "|") != "WARN low disk|ERROR cannot parse|FATAL giving up" {
+		t.Errorf("Unexpected async hook calls: %v", async)
+	}
+	mu.Unlock()
+	if n := strings.Count(errbuf.String(), "panicked: broken hook"); n != 1 {
+		t.Errorf("Expected 1 reported panic, got: %s", errbuf.String())
+	}
+	if n := strings.Count(errbuf.String(), "failed: webhook down"); n != 2 {
+		t.Errorf("Expected 2 reported errors, got: %s", errbuf.String())
+	}
+}
+
+func TestAsyncHookDrops(t *testing.T) {
+	l := NewLoggerFromFile(&bytes.Buffer{}, OFF, false)
+	release := make(chan struct{})
+	l.AddAsyncHook(HookFunc(func(r *Record) error {
+		<-release
+		return nil
+	}), ERROR, 2)
+	for i := 0; i < 10; i++ {
+		l.Error("error %d", i)
+	}
+	close(release)
+	l.Flush()
+	if d := l.HooksDropped(); d < 7 {
+		t.Errorf("Expected at least 7 dropped records, got %d", d)
+	}
+}
+
+func TestCloseStopsAsyncHooks(t *testing.T) {
+	before := runtime.NumGoroutine()
+	l := NewLoggerFromFile(&bytes.Buffer{}, OFF, false)
+	var mu sync.Mutex
+	var fired []string
+	l.AddAsyncHook(HookFunc(func(r *Record) error {
+		mu.Lock()
+		fired = append(fired, r.Message)
+		mu.Unlock()
+		return nil
+	}), ERROR, 10)
+	l.Error("before close")
+	l.Close()
+	l.Error("after close")
+	l.Close()
+
+	mu.Lock()
+	if strings.Join(fired, "|") != "before close" || l.HooksDropped() != 1 {
+		t.Errorf("Unexpected hook calls %v, %d dropped", fired, l.HooksDropped())
+	}
+	mu.Unlock()
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i == 100 {
+			t.Fatalf("Hook goroutine still running")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,100 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultJournalSocket is the socket of the systemd journal.
+const DefaultJournalSocket = "/run/systemd/journal/socket"
+
+// JournalEncoder writes every Record in the native protocol of the systemd journal. The
+// priority is mapped from the LogLevel, source file and line, the function, the logger name
+// and the fields are written as journal fields. Field names are upper cased, characters
+// other than letters, digits and '_' are replaced by '_'.
+type JournalEncoder struct {
+	Identifier string
+}
+
+// Encode implements Encoder.
+func (e *JournalEncoder) Encode(buf *bytes.Buffer, r *Record, coloured bool) {
+	writeJournalField(buf, "MESSAGE", r.Message)
+	writeJournalField(buf, "PRIORITY", strconv.Itoa(syslogSeverity(r.Level)))
+	if e.Identifier != "" {
+		writeJournalField(buf, "SYSLOG_IDENTIFIER", e.Identifier)
+	}
+	if r.File != "" {
+		writeJournalField(buf, "CODE_FILE", r.File)
+		writeJournalField(buf, "CODE_LINE", strconv.Itoa(r.Line))
+	}
+	if r.PC != 0 {
+		writeJournalField(buf, "CODE_FUNC", funcOf(r.PC))
+	}
+	if r.Name != "" {
+		writeJournalField(buf, "LOGGER", r.Name)
+	}
+	for _, f := range r.Fields {
+		writeJournalField(buf, journalFieldName(f.Key), fmt.Sprint(f.Value))
+	}
+}
+
+// journalFieldName turns a key into a valid journal field name.
+func journalFieldName(key string) string {
+	name := strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z':
+			return c - 'a' + 'A'
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_':
+			return c
+		}
+		return '_'
+	}, key)
+	name = strings.TrimLeft(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "F" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// writeJournalField writes a field as 'NAME=value', or in the binary format for values
+// containing newlines.
+func writeJournalField(buf *bytes.Buffer, name string, value string) {
+	buf.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// NewJournalSink creates a Sink writing native journal entries to the systemd journal.
+// socket defaults to DefaultJournalSocket, identifier to the name of the program. Every
+// message is sent as one datagram, so very large messages may be rejected by the journal.
+func NewJournalSink(socket string, level LogLevel, identifier string) (*Sink, error) {
+	if socket == "" {
+		socket = DefaultJournalSocket
+	}
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+	conn, err := dialDatagrams("unixgram", socket)
+	if err != nil {
+		return nil, err
+	}
+	return NewSink(conn, level, false, &JournalEncoder{Identifier: identifier}), nil
+}
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestJournalSink(t *testing.T) {
+	pc, addr := listen(t, "unixgram")
+	sink, err := NewJournalSink(addr, ALL, "grid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	l := NewLoggerFromFile(&bytes.Buffer{}, OFF, false)
+	l.AddSink(sink)
+	l.Errorw("two\nlines", "user-id", 42)
+
+	msg := receive(t, pc)
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], 9)
+	for _, want := range []string{"MESSAGE\n" + string(size[:]) + "two\nlines\n", "PRIORITY=3\n", "SYSLOG_IDENTIFIER=grid\n",
+		"CODE_LINE=", "CODE_FUNC=github.com/wlbr/commons/log.TestJournalSink\n", "USER_ID=42\n"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Expected %q in journal entry %q", want, msg)
+		}
+	}
+	if !strings.Contains(msg, "journal_test.go\n") {
+		t.Errorf("Missing source file in journal entry %q", msg)
+	}
+}
+
+func TestJournalFieldName(t *testing.T) {
+	for key, want := range map[string]string{"user-id": "USER_ID", "_secret": "SECRET", "1st": "F1ST", "Ok_2": "OK_2"} {
+		if got := journalFieldName(key); got != want {
+			t.Errorf("journalFieldName(%q) = %q, expected %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// levelHandlerMu serialises the requests of all LevelHandlers, so a PUT never mixes the
+// level of one request with the overrides of another.
+var levelHandlerMu sync.Mutex
+
+// levelVar holds the level set at runtime by SetLevel. It is shared between a Logger and
+// its children.
+type levelVar struct {
+	set   atomic.Bool
+	level atomic.Int32
+}
+
+// SetLevel changes the active level of the logger while the program is running. For a
+// named logger it sets the level override of its name, see SetLevelOverrides, so it applies
+// to all loggers of that name and to their named children, but not to the parent. For an
+// unnamed logger it changes the level of the logger and of all children without an
+// override, children created by With share the level of their parent. Unlike assigning
+// ActiveLoglevel it is safe for concurrent use, the level set takes precedence over
+// ActiveLoglevel.
+func (l *Logger) SetLevel(level LogLevel) {
+	if l.name != "" {
+		setLevelOverride(l.name, level)
+		return
+	}
+	lv := l.target().level
+	lv.level.Store(int32(level))
+	lv.set.Store(true)
+}
+
+// Level returns the active level of the logger, as set by SetLevel, SetLevelOverrides or
+// ActiveLoglevel.
+func (l *Logger) Level() LogLevel {
+	if level, ok := overrideFor(l.name); ok {
+		return level
+	}
+	t := l.target()
+	if t.level != nil && t.level.set.Load() {
+		return LogLevel(t.level.level.Load())
+	}
+	return t.ActiveLoglevel
+}
+
+// StepLevel makes the logger more verbose for positive steps and less verbose for negative
+// ones, e.g. 1 changes WARN to INFO. The level stays within OFF and ALL. The new level is
+// returned.
+func (l *Logger) StepLevel(steps int) LogLevel {
+	level := int(l.Level()) + steps
+	if level < int(OFF) {
+		level = int(OFF)
+	} else if level > int(ALL) {
+		level = int(ALL)
+	}
+	l.SetLevel(LogLevel(level))
+	return LogLevel(level)
+}
+
+// FormatLevelSpec formats a level and level overrides as accepted by ParseLevelSpec, e.g.
+// "warn,csv=debug". The names are sorted.
+func FormatLevelSpec(level LogLevel, overrides map[string]LogLevel) string {
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(levelName(level)))
+	for _, name := range names {
+		fmt.Fprintf(&sb, ",%s=%s", name, strings.ToLower(levelName(overrides[name])))
+	}
+	return sb.String()
+}
+
+// LevelHandler returns an http.Handler to read and change the levels of the convenience
+// logger and the named loggers at runtime. GET returns the current level specification,
+// e.g. "warn,csv=debug". PUT expects a specification in the same format (see
+// ParseLevelSpec) in the body, it replaces the level and all level overrides. The level is
+// kept if the specification contains named levels only. Requests are handled one after the
+// other, but level and overrides are changed in two steps, so a message logged during a PUT
+// may see the new level together with the old overrides.
+// The handler offers no authentication, it should only be served on a local address.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		levelHandlerMu.Lock()
+		defer levelHandlerMu.Unlock()
+		switch req.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut:
+			body, err := io.ReadAll(io.LimitReader(req.Body, 4096))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			l := logger()
+			level, overrides, err := ParseLevelSpec(string(body), l.Level())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			l.SetLevel(level)
+			SetLevelOverrides(overrides)
+			l.Warn("Log level changed to '%s'.", FormatLevelSpec(level, LevelOverrides()))
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, FormatLevelSpec(logger().Level(), LevelOverrides()))
+	})
+}
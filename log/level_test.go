@@ -0,0 +1,80 @@
+package log
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, WARN, false)
+	child := l.Named("csv")
+	l.Info("hidden")
+	l.SetLevel(INFO)
+	child.Info("shown")
+	if l.StepLevel(-10) != OFF || l.StepLevel(2) != ERROR || l.StepLevel(10) != ALL {
+		t.Errorf("Unexpected levels when stepping")
+	}
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "csv: shown") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+}
+
+func TestSetLevelNamed(t *testing.T) {
+	defer SetLevelOverrides(nil)
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, WARN, false)
+	csv := l.Named("csv")
+	csv.SetLevel(DEBUG)
+	l.Info("parent hidden")
+	csv.Named("reader").Debug("child shown")
+	l.With("file", "a.csv").Info("with hidden")
+	if l.Level() != WARN || csv.Level() != DEBUG || LevelOverrides()["csv"] != DEBUG {
+		t.Errorf("Unexpected levels: %v %v %v", l.Level(), csv.Level(), LevelOverrides())
+	}
+
+	defer SwapConvenienceLogger(SwapConvenienceLogger(l))
+	following := Named("astar")
+	following.SetLevel(OFF)
+	if logger().Level() != WARN || following.Level() != OFF {
+		t.Errorf("SetLevel of a following named logger changed the convenience logger")
+	}
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "csv.reader: child shown") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	defer SetLevelOverrides(nil)
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, WARN, false)
+	defer SwapConvenienceLogger(SwapConvenienceLogger(l))
+	h := LevelHandler()
+
+	do := func(method, body string) (int, string) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/loglevel", strings.NewReader(body)))
+		return w.Code, strings.TrimSpace(w.Body.String())
+	}
+	if code, body := do(http.MethodGet, ""); code != http.StatusOK || body != "warn" {
+		t.Errorf("GET returned %d %q", code, body)
+	}
+	if code, body := do(http.MethodPut, "info,csv=debug,Strategies.Astar=off"); code != http.StatusOK || body != "info,csv=debug,strategies.astar=off" {
+		t.Errorf("PUT returned %d %q", code, body)
+	}
+	if l.Level() != INFO || LevelOverrides()["csv"] != DEBUG {
+		t.Errorf("Levels not changed: %s %v", l.Level(), LevelOverrides())
+	}
+	if code, body := do(http.MethodPut, "csv=trace"); code != http.StatusOK || body != "info,csv=trace" {
+		t.Errorf("PUT returned %d %q", code, body)
+	}
+	if code, _ := do(http.MethodPut, "loud"); code != http.StatusBadRequest {
+		t.Errorf("PUT of an invalid level returned %d", code)
+	}
+	if code, _ := do(http.MethodPost, "info"); code != http.StatusMethodNotAllowed {
+		t.Errorf("POST returned %d", code)
+	}
+}
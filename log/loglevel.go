@@ -3,19 +3,21 @@ package log
 //go:generate enumer -type LogLevel loglevel.go
 
 import (
-	"fmt"
+	"bytes"
+	"context"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"strings"
-
-	"github.com/gookit/color"
+	"sync"
+	"sync/atomic"
 )
 
 // LogLevel sets the criticality of a logging output. It is used to filter logging messages
 // depending on their priority. Compare to log4j.
 // Level DEBUG should be used for temporary debugging information and should be removed
-// from production code.
+// from production code. Level TRACE is meant for very detailed, high volume output.
 type LogLevel int
 
 // The predefined LogLevels that are used by the logging funktions below.
@@ -26,19 +28,45 @@ const (
 	WARN
 	INFO
 	DEBUG
+	TRACE
 	ALL
 )
 
 var loggerflags = log.Ldate | log.Ltime | log.Llongfile | log.Lmicroseconds | log.LUTC
-var convenienceLogger *Logger
+var convenienceLogger atomic.Pointer[Logger]
 var colorizedOutput bool = true
 
+// developerMode lets DEBUG messages pass regardless of the active level.
+var developerMode atomic.Bool
+
+// standardLogger is used by the convenience functions as long as no convenience logger
+// is set. It writes into the output of the standard logger (package log) and uses its
+// flags, but it never modifies the standard logger.
+var standardLogger = &Logger{out: standardWriter{}, mu: &sync.Mutex{}, ActiveLoglevel: ALL,
+	UseColouredOutput: colorizedOutput, Encoder: standardEncoder{}, sinks: &sinkSet{},
+	sampler: &sampler{}, dedup: &deduplicator{}, hooks: &hookSet{}, level: &levelVar{},
+	crash: &crashBuffer{}, stats: &statsCounter{}}
+
 // A Logger is an onbject the offers several method to write Messages to a stream.
-// Atually it is a wrapper aroung the 'log' package, that enhances the LogLevel functionality.
+// Every message is formatted as a whole, including level prefix and colour, and written with
+// a single call to the stream. A Logger is safe for concurrent use.
 type Logger struct {
-	internallogger    *log.Logger
+	out               io.Writer
+	mu                *sync.Mutex
 	ActiveLoglevel    LogLevel
 	UseColouredOutput bool
+	Encoder           Encoder
+	fields            []Field
+	handler           slog.Handler
+	sinks             *sinkSet
+	sampler           *sampler
+	dedup             *deduplicator
+	hooks             *hookSet
+	level             *levelVar
+	crash             *crashBuffer
+	stats             *statsCounter
+	name              string
+	follow            bool
 }
 
 // NewLoggerFromFile creates a new Logger. It take a file parameter (io.Writer) output file
@@ -47,71 +75,239 @@ type Logger struct {
 // functions Log...() below). Afterwards created loggers will not overwrie this. The convenience
 // logger can be reset by using the method SetConvenienceLogger.
 func NewLoggerFromFile(logfile io.Writer, level LogLevel, useColouredOutput bool) *Logger {
+	return NewLoggerWithEncoder(logfile, level, useColouredOutput, nil)
+}
+
+// NewLoggerWithEncoder works like NewLoggerFromFile, but the messages are formatted by the
+// given Encoder (see TextEncoder, JSONEncoder and LogfmtEncoder). A nil Encoder results in
+// the classic text output.
+func NewLoggerWithEncoder(logfile io.Writer, level LogLevel, useColouredOutput bool, enc Encoder) *Logger {
 	l := &Logger{}
-	l.internallogger = log.New(logfile, "LOG: ", loggerflags)
+	l.out = logfile
+	l.mu = &sync.Mutex{}
 	l.ActiveLoglevel = level
 	l.UseColouredOutput = useColouredOutput
-	if convenienceLogger == nil {
-		convenienceLogger = l
-	}
+	l.Encoder = enc
+	l.sinks = &sinkSet{}
+	l.sampler = &sampler{}
+	l.dedup = &deduplicator{}
+	l.hooks = &hookSet{}
+	l.level = &levelVar{}
+	l.crash = &crashBuffer{}
+	l.stats = &statsCounter{}
+	convenienceLogger.CompareAndSwap(nil, l)
 	return l
 }
 
 // NewLogger creates a new Logger. It take a string file name as output file
 // and a LogLevel to filter the messages that are wanted.
 // The logger will use io.StdOut if the log filename string parameter is "STDERR"
+// If the log file can not be opened, the logger writes to STDERR.
+// Without useColouredLogging the output is coloured if it is a terminal, see ColourEnabled.
 func NewLogger(logfilename string, level LogLevel, useColouredLogging ...bool) *Logger {
-	var lfilename string
-	var logfile io.Writer
-	var useColouredOutput bool = false
+	logfile, _, err := openDestination(logfilename)
+	if err != nil {
+		logfile = os.Stderr
+	}
+	var useColouredOutput bool
 	if len(useColouredLogging) > 0 {
 		useColouredOutput = useColouredLogging[0]
+	} else {
+		useColouredOutput = ColourEnabled(ColourAuto, logfile)
 	}
+	return NewLoggerFromFile(logfile, level, useColouredOutput)
+}
+
+// openDestination opens the named log file for appending. For the names accepted by
+// IsConsoleName the console is returned instead.
+func openDestination(logfilename string) (logfile io.Writer, console bool, err error) {
 	if logfilename == "" || strings.ToUpper(logfilename) == "STDERR" {
-		lfilename = "<STDERR>"
-		logfile = os.Stderr
-		if len(useColouredLogging) == 0 {
-			useColouredOutput = true
-		}
+		return os.Stderr, true, nil
 	} else if strings.ToUpper(logfilename) == "STDOUT" {
-		lfilename = "<STDOUT>"
-		logfile = os.Stderr
-		if len(useColouredLogging) == 0 {
-			useColouredOutput = true
-		}
-	} else {
-		lfilename = logfilename
-		logfile, _ = os.OpenFile(lfilename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		return os.Stderr, true, nil
 	}
-	return NewLoggerFromFile(logfile, level, useColouredOutput)
+	f, err := openLogFile(logfilename)
+	if err != nil {
+		return nil, false, err
+	}
+	return f, false, nil
+}
+
+// isConsole reports whether w is STDERR or STDOUT.
+func isConsole(w io.Writer) bool {
+	return w == io.Writer(os.Stderr) || w == io.Writer(os.Stdout)
+}
+
+// levelName returns the upper case name of a level as it is written into the log.
+func levelName(level LogLevel) string {
+	return strings.TrimSuffix(strings.ToUpper(level.String()), "LEVEL")
+}
+
+// IsConsoleName reports whether NewLogger writes to the console instead of a file for the
+// given log file name, i.e. the name is empty, "STDERR" or "STDOUT".
+func IsConsoleName(logfilename string) bool {
+	n := strings.ToUpper(logfilename)
+	return n == "" || n == "STDERR" || n == "STDOUT"
+}
+
+// levelEnabled reports whether a message of the given level passes the active level.
+func levelEnabled(active LogLevel, level LogLevel) bool {
+	return active >= level || (level == DEBUG && developerMode.Load())
+}
+
+// SetDeveloperMode switches the developer mode on or off. In developer mode DEBUG messages
+// are printed regardless of the active log level. It is off by default.
+func SetDeveloperMode(on bool) {
+	developerMode.Store(on)
+}
+
+// DeveloperMode reports whether the developer mode is switched on.
+func DeveloperMode() bool {
+	return developerMode.Load()
+}
+
+// target returns the logger whose destination and settings are used for writing. Loggers
+// derived from the package level function Named follow the current convenience logger.
+func (l *Logger) target() *Logger {
+	if l.follow {
+		return logger()
+	}
+	return l
+}
+
+// limits returns the active level of the loggers own stream and the upper limit for its
+// sinks, taking the level overrides for the given logger name into account.
+func (l *Logger) limits(name string) (active LogLevel, sinkLimit LogLevel) {
+	if level, ok := overrideFor(name); ok {
+		return level, level
+	}
+	return l.Level(), ALL
+}
+
+func (l *Logger) isEnabled(level LogLevel) bool {
+	t := l.target()
+	return t.crash.enabled() || t.visible(l.name, level)
 }
 
-func colorize(level LogLevel, s string) string {
-	var c color.Color
-	switch level {
-	case FATAL:
-		c = color.Red
-	case ERROR:
-		c = color.LightRed
-	case WARN:
-		c = color.Yellow
-	case DEBUG:
-		c = color.LightBlue
-	case INFO:
-		c = color.LightCyan
+// visible reports whether messages of the level of the named logger are written to any
+// output stream or hook.
+func (l *Logger) visible(name string, level LogLevel) bool {
+	active, sinkLimit := l.limits(name)
+	if l.hooks.enabled(level) {
+		return true
+	}
+	if l.handler != nil {
+		return levelEnabled(sinkLimit, level) && l.handler.Enabled(context.Background(), slogLevel(level))
+	}
+	if levelEnabled(active, level) {
+		return true
 	}
-	return c.Sprintf("%s", s)
+	for _, s := range l.sinks.list() {
+		if s.isEnabled(level, active, sinkLimit) {
+			return true
+		}
+	}
+	return false
 }
 
 func (l *Logger) writelog(level LogLevel, format string, args ...interface{}) {
-	if level == DEBUG || l.ActiveLoglevel >= level {
-		prefix := fmt.Sprintf("%5s: ", strings.TrimSuffix(strings.ToUpper(level.String()), "LEVEL"))
-		if l.UseColouredOutput {
-			prefix = colorize(level, prefix)
+	if l.isEnabled(level) {
+		l.output(4, level, format, sprintf(format, args), nil)
+	}
+}
+
+// baseFields returns the fields attached to the logger. Loggers following the convenience
+// logger inherit its fields.
+func (l *Logger) baseFields() []Field {
+	if l.follow {
+		return append(append([]Field(nil), logger().fields...), l.fields...)
+	}
+	return l.fields
+}
+
+// output writes msg together with the loggers fields and the given fields. calldepth is
+// the number of stack frames up to the code that issued the log call, counting output as 1.
+// template is the unformatted message, it identifies similar messages for sampling.
+func (l *Logger) output(calldepth int, level LogLevel, template string, msg string, fields []Field) {
+	r := newRecord(calldepth, level, msg, l.baseFields(), fields)
+	r.Name = l.name
+	r.template = template
+	l.write(r)
+}
+
+// outputAt works like output, but attributes the message to the code at the program
+// counter pc, e.g. the function that panicked.
+func (l *Logger) outputAt(pc uintptr, level LogLevel, template string, msg string, fields []Field) {
+	r := newRecord(1, level, msg, l.baseFields(), fields)
+	if pc != 0 {
+		r.PC = pc
+		r.File, r.Line = sourceOf(pc)
+	}
+	r.Name = l.name
+	r.template = template
+	l.write(r)
+}
+
+// write masks secrets in a Record, that already passed the level filter, and keeps it in
+// the crash buffer. Records written by any output stream are counted and handed to
+// sampling, deduplication and then to the output streams. Errors write the crash buffer
+// before.
+func (l *Logger) write(r *Record) {
+	t := l.target()
+	redactRecord(r)
+	if r.Level > ERROR {
+		t.keep(r)
+	}
+	if !t.visible(r.Name, r.Level) {
+		return
+	}
+	t.stats.add(r)
+	if !t.sampler.admit(r) {
+		return
+	}
+	repeated, ok := t.dedup.check(r)
+	if repeated != nil {
+		t.emit(repeated)
+	}
+	if ok {
+		if r.Level <= ERROR {
+			t.dumpCrashBuffer(r)
+		}
+		t.emit(r)
+	}
+}
+
+// emit writes a Record to the output streams of the logger and its sinks and fires the
+// hooks afterwards.
+func (l *Logger) emit(r *Record) {
+	defer l.hooks.fire(r)
+	active, sinkLimit := l.limits(r.Name)
+	if l.handler != nil {
+		if levelEnabled(sinkLimit, r.Level) && l.handler.Enabled(context.Background(), slogLevel(r.Level)) {
+			l.forwardToHandler(r)
 		}
-		l.internallogger.SetPrefix(prefix)
-		l.internallogger.Output(3, fmt.Sprintf(format, args...))
+		return
+	}
+	if levelEnabled(active, r.Level) {
+		l.writeOut(r)
+	}
+	for _, s := range l.sinks.list() {
+		s.write(r, active, sinkLimit)
+	}
+}
+
+// writeOut encodes and writes a Record to the loggers own stream, with a single call to the
+// stream.
+func (l *Logger) writeOut(r *Record) {
+	enc := l.Encoder
+	if enc == nil {
+		enc = defaultEncoder
 	}
+	var buf bytes.Buffer
+	enc.Encode(&buf, r, l.UseColouredOutput)
+	l.mu.Lock()
+	l.out.Write(buf.Bytes())
+	l.mu.Unlock()
 }
 
 // Info works just as fmt.Printf, but prints into the loggers stream.
@@ -139,83 +335,108 @@ func (l *Logger) Fatal(format string, args ...interface{}) {
 }
 
 // Debug works just as fmt.Printf, but prints into the loggers stream.
-// The message is printed anyway, regardless of the log level.
+// The message is only printed if ActiveLogLevel is set higher or equal to 'Debug', or
+// if the developer mode is switched on (see SetDeveloperMode).
 func (l *Logger) Debug(format string, args ...interface{}) {
 	l.writelog(DEBUG, format, args...)
 }
 
+// Trace works just as fmt.Printf, but prints into the loggers stream.
+// The message is only printed if ActiveLogLevel is set higher or equal to 'Trace'
+func (l *Logger) Trace(format string, args ...interface{}) {
+	l.writelog(TRACE, format, args...)
+}
+
 // -----------------------------
 
 // SetConvenienceLogger sets a logger as a singleton object. The LogInfo etc.
 // functions use this singleton to offer logging function without an object context.
+// Loggers returned by the package level function Named already follow the convenience
+// logger, setting one of them is ignored.
 func (l *Logger) SetConvenienceLogger() {
-	convenienceLogger = l
+	if l.follow {
+		return
+	}
+	convenienceLogger.Store(l)
 }
 
-func outputToStandardLogger(level LogLevel, format string, args ...interface{}) {
-	p := log.Prefix()
-	f := log.Flags()
-	prefix := fmt.Sprintf("%5s: ", strings.TrimSuffix(strings.ToUpper(level.String()), "LEVEL"))
-	if colorizedOutput {
-		prefix = colorize(level, prefix)
+// SwapConvenienceLogger sets l as the convenience logger and returns the previous one, so
+// it can be restored later. Both may be nil, meaning that the convenience functions use the
+// standard logger. Loggers returned by the package level function Named are ignored.
+func SwapConvenienceLogger(l *Logger) *Logger {
+	if l != nil && l.follow {
+		return convenienceLogger.Load()
 	}
-	log.SetPrefix(prefix)
-	log.Output(3, fmt.Sprintf(format, args...))
-	log.SetPrefix(p)
-	log.SetFlags(f)
+	return convenienceLogger.Swap(l)
+}
+
+// ConvenienceLogger returns the logger used by the convenience functions.
+func ConvenienceLogger() *Logger {
+	return logger()
+}
+
+// logger returns the convenience logger, or the standardLogger if it is unset.
+func logger() *Logger {
+	if l := convenienceLogger.Load(); l != nil {
+		return l
+	}
+	return standardLogger
+}
+
+// standardWriter writes into the current output of the standard logger.
+type standardWriter struct{}
+
+func (standardWriter) Write(p []byte) (int, error) {
+	return log.Writer().Write(p)
+}
+
+// standardEncoder is a TextEncoder using the current flags of the standard logger.
+type standardEncoder struct{}
+
+func (standardEncoder) Encode(buf *bytes.Buffer, r *Record, coloured bool) {
+	coloured = coloured && ColourEnabled(ColourAuto, log.Writer())
+	(&TextEncoder{Flags: log.Flags()}).Encode(buf, r, coloured)
 }
 
 // LogInfo works just as fmt.Printf, but prints into the Convenience loggers stream, as set with
 // SetConvenienceLogger(). It uses the standard logger (package log) if te Convenience logger is unset.
 // The message is only printed if ActiveLogLevel is set higher or equal to 'Info'
 func Info(format string, args ...interface{}) {
-	if convenienceLogger != nil {
-		convenienceLogger.writelog(INFO, format, args...)
-	} else {
-		outputToStandardLogger(INFO, format, args...)
-	}
+	logger().writelog(INFO, format, args...)
 }
 
 // LogDebug works just as fmt.Printf, but prints into the Convenience loggers stream, as set with
 // SetConvenienceLogger(). It uses the standard logger (package log) if te Convenience logger is unset.
-// The message is only printed if ActiveLogLevel is set higher or equal to 'Debug'
+// The message is only printed if ActiveLogLevel is set higher or equal to 'Debug', or
+// if the developer mode is switched on (see SetDeveloperMode).
 func Debug(format string, args ...interface{}) {
-	if convenienceLogger != nil {
-		convenienceLogger.writelog(DEBUG, format, args...)
-	} else {
-		outputToStandardLogger(DEBUG, format, args...)
-	}
+	logger().writelog(DEBUG, format, args...)
+}
+
+// Trace works just as fmt.Printf, but prints into the Convenience loggers stream, as set with
+// SetConvenienceLogger(). It uses the standard logger (package log) if te Convenience logger is unset.
+// The message is only printed if ActiveLogLevel is set higher or equal to 'Trace'
+func Trace(format string, args ...interface{}) {
+	logger().writelog(TRACE, format, args...)
 }
 
 // LogWarn works just as fmt.Printf, but prints into the Convenience loggers stream, as set with
 // SetConvenienceLogger(). It uses the standard logger (package log) if te Convenience logger is unset.
 // The message is only printed if ActiveLogLevel is set higher or equal to 'Warn'
 func Warn(format string, args ...interface{}) {
-	if convenienceLogger != nil {
-		convenienceLogger.writelog(WARN, format, args...)
-	} else {
-		outputToStandardLogger(WARN, format, args...)
-	}
+	logger().writelog(WARN, format, args...)
 }
 
 // LogError works just as fmt.Printf, but prints into the Convenience loggers stream, as set with
 // SetConvenienceLogger(). It uses the standard logger (package log) if te Convenience logger is unset.
 // The message is only printed if ActiveLogLevel is set higher or equal to 'Error'
 func Error(format string, args ...interface{}) {
-	if convenienceLogger != nil {
-		convenienceLogger.writelog(ERROR, format, args...)
-	} else {
-		outputToStandardLogger(ERROR, format, args...)
-	}
+	logger().writelog(ERROR, format, args...)
 }
 
 // Fatal works just as fmt.Printf, but prints into the Convenience loggers stream, as set with
 // SetConvenienceLogger(). It uses the standard logger (package log) if te Convenience logger is unset.
 // The message is only printed if ActiveLogLevel is set hogher or equal to 'Fatal'
 func Fatal(format string, args ...interface{}) {
-	if convenienceLogger != nil {
-		convenienceLogger.writelog(FATAL, format, args...)
-	} else {
-		outputToStandardLogger(FATAL, format, args...)
-	}
+	logger().writelog(FATAL, format, args...)
 }
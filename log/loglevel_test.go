@@ -0,0 +1,59 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDebugRespectsLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, WARN, false)
+	l.Debug("hidden debug")
+	l.Trace("hidden trace")
+	if buf.Len() != 0 {
+		t.Errorf("DEBUG or TRACE written at level WARN: %s", buf.String())
+	}
+
+	SetDeveloperMode(true)
+	defer SetDeveloperMode(false)
+	l.Debug("developer debug")
+	l.Trace("hidden trace")
+	if !strings.Contains(buf.String(), "DEBUG: ") || strings.Contains(buf.String(), "hidden") {
+		t.Errorf("Unexpected output in developer mode: %s", buf.String())
+	}
+}
+
+func TestTrace(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, DEBUG, false)
+	l.Trace("hidden")
+	l.ActiveLoglevel = TRACE
+	l.Tracew("shown", "step", 1)
+	if buf.String() == "" || !strings.HasPrefix(buf.String(), "TRACE: ") || strings.Contains(buf.String(), "hidden") {
+		t.Errorf("Unexpected output: %s", buf.String())
+	}
+	if level, err := LogLevelString("TRACE"); err != nil || level != TRACE || TRACE >= ALL || TRACE <= DEBUG {
+		t.Errorf("TRACE is not placed between DEBUG and ALL: %v %v", level, err)
+	}
+}
+
+func TestNewLoggerFallsBackToStderr(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stderr := os.Stderr
+	os.Stderr = f
+	defer func() { os.Stderr = stderr }()
+
+	l := NewLogger(filepath.Join(t.TempDir(), "missing", "x.log"), ALL, false)
+	l.Info("still logging")
+	b, _ := os.ReadFile(f.Name())
+	if !strings.Contains(string(b), "still logging") {
+		t.Errorf("Expected the message on STDERR, got %q", b)
+	}
+}
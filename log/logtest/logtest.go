@@ -0,0 +1,148 @@
+// Package logtest offers a recording logger for tests. It captures every written message
+// together with its level, fields and caller, so tests can assert on what was logged
+// without redirecting files.
+package logtest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wlbr/commons/log"
+)
+
+// A Recorder captures the records written by a logger. It is used as the Encoder of the
+// logger, so the records are captured exactly as they would be written. A Recorder is safe
+// for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	records []log.Record
+}
+
+// Encode implements log.Encoder. It captures a copy of r and writes nothing.
+func (rec *Recorder) Encode(buf *bytes.Buffer, r *log.Record, coloured bool) {
+	c := *r
+	c.Fields = append([]log.Field(nil), r.Fields...)
+	rec.mu.Lock()
+	rec.records = append(rec.records, c)
+	rec.mu.Unlock()
+}
+
+// New creates a Logger capturing all levels into the returned Recorder.
+func New() (*log.Logger, *Recorder) {
+	rec := &Recorder{}
+	return log.NewLoggerWithEncoder(io.Discard, log.ALL, false, rec), rec
+}
+
+// Install creates a recording Logger and sets it as the convenience logger for the test t.
+// The previous convenience logger is restored when the test and its subtests are finished.
+// Tests using Install must not run in parallel.
+func Install(t testing.TB) *Recorder {
+	t.Helper()
+	previous := log.SwapConvenienceLogger(nil)
+	l, rec := New()
+	log.SwapConvenienceLogger(l)
+	t.Cleanup(func() { log.SwapConvenienceLogger(previous) })
+	return rec
+}
+
+// Records returns a copy of all captured records.
+func (rec *Recorder) Records() []log.Record {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]log.Record(nil), rec.records...)
+}
+
+// Reset forgets all captured records.
+func (rec *Recorder) Reset() {
+	rec.mu.Lock()
+	rec.records = nil
+	rec.mu.Unlock()
+}
+
+// Find returns the captured records of a level whose message contains substr.
+func (rec *Recorder) Find(level log.LogLevel, substr string) []log.Record {
+	var found []log.Record
+	for _, r := range rec.Records() {
+		if r.Level == level && strings.Contains(r.Message, substr) {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+// Field returns the value of the first field of r with the given key.
+func Field(r log.Record, key string) (interface{}, bool) {
+	for _, f := range r.Fields {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// String returns all captured records, one per line, for error messages.
+func (rec *Recorder) String() string {
+	var sb strings.Builder
+	for _, r := range rec.Records() {
+		fmt.Fprintf(&sb, "%5s: %s", r.Level, r.Message)
+		for _, f := range r.Fields {
+			fmt.Fprintf(&sb, " %s=%v", f.Key, f.Value)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// AssertLogged fails the test if no message of the level containing substr was captured.
+func (rec *Recorder) AssertLogged(t testing.TB, level log.LogLevel, substr string) {
+	t.Helper()
+	if len(rec.Find(level, substr)) == 0 {
+		t.Errorf("Expected a %s message containing %q, got:\n%s", level, substr, rec)
+	}
+}
+
+// AssertNotLogged fails the test if a message of the level containing substr was captured.
+func (rec *Recorder) AssertNotLogged(t testing.TB, level log.LogLevel, substr string) {
+	t.Helper()
+	if found := rec.Find(level, substr); len(found) > 0 {
+		t.Errorf("Expected no %s message containing %q, got %q", level, substr, found[0].Message)
+	}
+}
+
+// AssertCount fails the test if the number of captured messages of the level differs from n.
+func (rec *Recorder) AssertCount(t testing.TB, level log.LogLevel, n int) {
+	t.Helper()
+	if got := len(rec.Find(level, "")); got != n {
+		t.Errorf("Expected %d %s messages, got %d:\n%s", n, level, got, rec)
+	}
+}
+
+// -----------------------------
+
+// installed returns the Recorder of the current convenience logger, as set by Install.
+func installed(t testing.TB) *Recorder {
+	t.Helper()
+	if rec, ok := log.ConvenienceLogger().Encoder.(*Recorder); ok {
+		return rec
+	}
+	t.Fatalf("No recording convenience logger installed, use logtest.Install")
+	return nil
+}
+
+// AssertLogged fails the test if no message of the level containing substr was captured by
+// the recorder set with Install.
+func AssertLogged(t testing.TB, level log.LogLevel, substr string) {
+	t.Helper()
+	installed(t).AssertLogged(t, level, substr)
+}
+
+// AssertNotLogged fails the test if a message of the level containing substr was captured by
+// the recorder set with Install.
+func AssertNotLogged(t testing.TB, level log.LogLevel, substr string) {
+	t.Helper()
+	installed(t).AssertNotLogged(t, level, substr)
+}
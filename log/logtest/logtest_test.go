@@ -0,0 +1,60 @@
+package logtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/wlbr/commons/log"
+)
+
+func TestInstall(t *testing.T) {
+	before := log.ConvenienceLogger()
+	t.Run("recording", func(t *testing.T) {
+		rec := Install(t)
+		log.Warn("disk %d%% full", 93)
+		log.Named("csv").Infow("parsed", "rows", 12)
+		log.Debug("details")
+
+		AssertLogged(t, log.WARN, "93% full")
+		AssertNotLogged(t, log.ERROR, "full")
+		rec.AssertCount(t, log.INFO, 1)
+
+		r := rec.Find(log.INFO, "parsed")[0]
+		if rows, ok := Field(r, "rows"); !ok || rows != 12 || r.Name != "csv" {
+			t.Errorf("Unexpected record: %+v", r)
+		}
+		if !strings.HasSuffix(r.File, "logtest_test.go") || r.Line == 0 {
+			t.Errorf("Unexpected caller %s:%d", r.File, r.Line)
+		}
+		rec.Reset()
+		rec.AssertCount(t, log.DEBUG, 0)
+	})
+	if log.ConvenienceLogger() != before {
+		t.Errorf("Convenience logger was not restored")
+	}
+}
+
+// failRecorder records the failures reported by the assertions instead of failing the test.
+type failRecorder struct {
+	testing.TB
+	failures []string
+}
+
+func (f *failRecorder) Helper() {}
+
+func (f *failRecorder) Errorf(format string, args ...interface{}) {
+	f.failures = append(f.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertionsFail(t *testing.T) {
+	l, rec := New()
+	l.Error("boom")
+	ft := &failRecorder{TB: t}
+	rec.AssertLogged(ft, log.WARN, "boom")
+	rec.AssertNotLogged(ft, log.ERROR, "boom")
+	rec.AssertCount(ft, log.ERROR, 2)
+	if len(ft.failures) != 3 || !strings.Contains(ft.failures[1], `"boom"`) {
+		t.Errorf("Expected 3 failures, got %q", ft.failures)
+	}
+}
@@ -0,0 +1,128 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// levelOverrides maps logger names to the LogLevel that overrides the ActiveLoglevel for
+// the loggers of that name.
+var levelOverrides atomic.Pointer[map[string]LogLevel]
+
+// Named returns a child logger with the given name. Names are hierarchical, naming a named
+// logger again joins the names with a dot, e.g. "strategies.astar". The active level of a
+// named logger can be overridden using SetLevelOverrides.
+func (l *Logger) Named(name string) *Logger {
+	child := *l
+	if l.name != "" {
+		child.name = l.name + "." + name
+	} else {
+		child.name = name
+	}
+	return &child
+}
+
+// Name returns the name of the logger, or the empty string for unnamed loggers.
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// Named returns a named logger writing into the convenience logger. It follows the
+// convenience logger, so it may be created in a package level variable before the
+// convenience logger is set:
+//
+//	var logger = log.Named("csv")
+func Named(name string) *Logger {
+	return followingLogger.Named(name)
+}
+
+// SetLevelOverrides sets the levels of named loggers. A named logger uses the level of the
+// most specific matching name, e.g. the logger "strategies.astar" uses the level given for
+// "strategies.astar", then the one for "strategies". If no name matches, the ActiveLoglevel
+// of the logger is used. For the sinks of a logger the override is an upper limit.
+// A nil or empty map removes all overrides.
+func SetLevelOverrides(overrides map[string]LogLevel) {
+	m := make(map[string]LogLevel, len(overrides))
+	for name, level := range overrides {
+		m[strings.ToLower(name)] = level
+	}
+	levelOverrides.Store(&m)
+}
+
+// setLevelOverride sets the level override of a single name, keeping the other ones.
+func setLevelOverride(name string, level LogLevel) {
+	for {
+		old := levelOverrides.Load()
+		m := make(map[string]LogLevel)
+		if old != nil {
+			for n, l := range *old {
+				m[n] = l
+			}
+		}
+		m[strings.ToLower(name)] = level
+		if levelOverrides.CompareAndSwap(old, &m) {
+			return
+		}
+	}
+}
+
+// LevelOverrides returns a copy of the current level overrides.
+func LevelOverrides() map[string]LogLevel {
+	m := make(map[string]LogLevel)
+	if p := levelOverrides.Load(); p != nil {
+		for name, level := range *p {
+			m[name] = level
+		}
+	}
+	return m
+}
+
+// overrideFor returns the overriding level for the most specific matching name.
+func overrideFor(name string) (LogLevel, bool) {
+	if name == "" {
+		return 0, false
+	}
+	p := levelOverrides.Load()
+	if p == nil || len(*p) == 0 {
+		return 0, false
+	}
+	name = strings.ToLower(name)
+	for {
+		if level, ok := (*p)[name]; ok {
+			return level, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return 0, false
+		}
+		name = name[:i]
+	}
+}
+
+// ParseLevelSpec parses a level specification like "warn,csv=debug,astar=off". The entry
+// without a name sets the default level, it is def if missing. The entries with names are
+// returned as overrides for SetLevelOverrides. Level names are case insensitive.
+func ParseLevelSpec(spec string, def LogLevel) (LogLevel, map[string]LogLevel, error) {
+	overrides := make(map[string]LogLevel)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, levelname, named := strings.Cut(entry, "=")
+		if !named {
+			levelname = name
+		}
+		level, err := LogLevelString(strings.ToUpper(strings.TrimSpace(levelname)))
+		if err != nil {
+			return def, overrides, fmt.Errorf("invalid level in '%s': %w", entry, err)
+		}
+		if named {
+			overrides[strings.TrimSpace(name)] = level
+		} else {
+			def = level
+		}
+	}
+	return def, overrides, nil
+}
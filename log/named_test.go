@@ -0,0 +1,64 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseLevelSpec(t *testing.T) {
+	def, overrides, err := ParseLevelSpec("warn, csv=debug,astar=Off", INFO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def != WARN || len(overrides) != 2 || overrides["csv"] != DEBUG || overrides["astar"] != OFF {
+		t.Errorf("Unexpected result: %v %v", def, overrides)
+	}
+	if def, _, _ := ParseLevelSpec("csv=debug", INFO); def != INFO {
+		t.Errorf("Default level should be kept, but is %v", def)
+	}
+	if _, _, err := ParseLevelSpec("warn,csv=loud", INFO); err == nil {
+		t.Error("Invalid level should be reported")
+	}
+}
+
+func TestNamedOverrides(t *testing.T) {
+	defer SetLevelOverrides(nil)
+	var buf, sinkbuf bytes.Buffer
+	l := NewLoggerFromFile(&buf, WARN, false)
+	l.AddSink(NewSink(&sinkbuf, ALL, false, nil))
+	SetLevelOverrides(map[string]LogLevel{"csv": INFO, "strategies": OFF, "strategies.astar": ERROR})
+
+	l.Named("csv").Info("csv info")
+	l.Named("strategies").Named("board").Error("board error")
+	l.Named("strategies").Named("astar").Error("astar error")
+	l.Named("tools").Info("tools info")
+
+	out := buf.String()
+	if !strings.Contains(out, "csv: csv info") || !strings.Contains(out, "strategies.astar: astar error") {
+		t.Errorf("Missing messages: %s", out)
+	}
+	if strings.Contains(out, "board error") || strings.Contains(out, "tools info") {
+		t.Errorf("Filtered messages were written: %s", out)
+	}
+	if strings.Contains(sinkbuf.String(), "board error") || !strings.Contains(sinkbuf.String(), "tools info") {
+		t.Errorf("Unexpected sink output: %s", sinkbuf.String())
+	}
+}
+
+func TestNamedFollowsConvenienceLogger(t *testing.T) {
+	old := convenienceLogger.Load()
+	defer convenienceLogger.Store(old)
+	named := Named("csv").With("file", "a.csv")
+	var buf bytes.Buffer
+	NewLoggerFromFile(&buf, ALL, false).SetConvenienceLogger()
+	named.Warn("late")
+	named.SetConvenienceLogger()
+	Info("still working")
+	if !strings.Contains(buf.String(), "named_test.go") || !strings.Contains(buf.String(), "csv: late file=a.csv") {
+		t.Errorf("Unexpected output: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "still working") {
+		t.Errorf("Convenience logger was replaced by a named logger: %s", buf.String())
+	}
+}
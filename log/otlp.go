@@ -0,0 +1,151 @@
+package log
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// otlpSeverity maps a LogLevel to the OpenTelemetry severity number.
+func otlpSeverity(level LogLevel) int {
+	switch level {
+	case FATAL:
+		return 21
+	case ERROR:
+		return 17
+	case WARN:
+		return 13
+	case INFO:
+		return 9
+	case DEBUG:
+		return 5
+	}
+	return 1
+}
+
+// OTLPEncoder writes every Record as an OTLP/JSON ExportLogsServiceRequest on a single
+// line, as read by the otlpjsonfile receiver of the OpenTelemetry collector. The Resource
+// fields become resource attributes, e.g. service.name and service.version. The name of a
+// named logger is used as instrumentation scope. The fields TraceIDKey and SpanIDKey (see
+// ContextWithTrace) become the trace and span id of the log record, source file, line and
+// function become code.* attributes and all other fields plain attributes.
+type OTLPEncoder struct {
+	Resource []Field
+}
+
+// NewOTLPEncoder creates an OTLPEncoder with resource attributes given as key/value pairs.
+func NewOTLPEncoder(resource ...interface{}) *OTLPEncoder {
+	return &OTLPEncoder{Resource: toFields(resource)}
+}
+
+// Encode implements Encoder.
+func (e *OTLPEncoder) Encode(buf *bytes.Buffer, r *Record, coloured bool) {
+	buf.WriteString(`{"resourceLogs":[{"resource":{"attributes":`)
+	writeOTLPAttributes(buf, e.Resource)
+	buf.WriteString(`},"scopeLogs":[{"scope":{"name":`)
+	writeJSONValue(buf, r.Name)
+	buf.WriteString(`},"logRecords":[{"timeUnixNano":"`)
+	buf.WriteString(strconv.FormatInt(r.Time.UnixNano(), 10))
+	buf.WriteString(`","severityNumber":`)
+	buf.WriteString(strconv.Itoa(otlpSeverity(r.Level)))
+	buf.WriteString(`,"severityText":`)
+	writeJSONValue(buf, levelName(r.Level))
+	buf.WriteString(`,"body":{"stringValue":`)
+	writeJSONValue(buf, r.Message)
+	buf.WriteString(`},"attributes":`)
+
+	attributes := []Field{F("code.filepath", r.File), F("code.lineno", r.Line)}
+	if r.PC != 0 {
+		attributes = append(attributes, F("code.function", funcOf(r.PC)))
+	}
+	var traceID, spanID string
+	for _, f := range r.Fields {
+		switch {
+		case f.Key == TraceIDKey && isHexID(f.Value, 16):
+			traceID = fmt.Sprint(f.Value)
+		case f.Key == SpanIDKey && isHexID(f.Value, 8):
+			spanID = fmt.Sprint(f.Value)
+		default:
+			attributes = append(attributes, f)
+		}
+	}
+	writeOTLPAttributes(buf, attributes)
+	if traceID != "" {
+		buf.WriteString(`,"traceId":"` + traceID + `"`)
+	}
+	if spanID != "" {
+		buf.WriteString(`,"spanId":"` + spanID + `"`)
+	}
+	buf.WriteString("}]}]}]}\n")
+}
+
+// isHexID reports whether v is a hex encoded id of the given number of bytes.
+func isHexID(v interface{}, size int) bool {
+	s, ok := v.(string)
+	if !ok || len(s) != 2*size {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func writeOTLPAttributes(buf *bytes.Buffer, fields []Field) {
+	buf.WriteByte('[')
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`{"key":`)
+		writeJSONValue(buf, f.Key)
+		buf.WriteString(`,"value":`)
+		writeOTLPValue(buf, f.Value)
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+}
+
+// writeOTLPValue writes v as an OTLP AnyValue. 64 bit integers are written as strings, as
+// demanded by the JSON mapping of protobuf.
+func writeOTLPValue(buf *bytes.Buffer, v interface{}) {
+	switch x := v.(type) {
+	case bool:
+		buf.WriteString(`{"boolValue":` + strconv.FormatBool(x) + `}`)
+	case int:
+		buf.WriteString(`{"intValue":"` + strconv.FormatInt(int64(x), 10) + `"}`)
+	case int8, int16, int32, int64, uint8, uint16, uint32:
+		buf.WriteString(`{"intValue":"` + fmt.Sprint(x) + `"}`)
+	case float32:
+		writeOTLPDouble(buf, float64(x))
+	case float64:
+		writeOTLPDouble(buf, x)
+	case error:
+		buf.WriteString(`{"stringValue":`)
+		writeJSONValue(buf, x.Error())
+		buf.WriteByte('}')
+	case string:
+		buf.WriteString(`{"stringValue":`)
+		writeJSONValue(buf, x)
+		buf.WriteByte('}')
+	default:
+		buf.WriteString(`{"stringValue":`)
+		writeJSONValue(buf, fmt.Sprint(x))
+		buf.WriteByte('}')
+	}
+}
+
+func writeOTLPDouble(buf *bytes.Buffer, f float64) {
+	switch {
+	case math.IsNaN(f):
+		buf.WriteString(`{"doubleValue":"NaN"}`)
+		return
+	case math.IsInf(f, 1):
+		buf.WriteString(`{"doubleValue":"Infinity"}`)
+		return
+	case math.IsInf(f, -1):
+		buf.WriteString(`{"doubleValue":"-Infinity"}`)
+		return
+	}
+	buf.WriteString(`{"doubleValue":` + strconv.FormatFloat(f, 'g', -1, 64) + `}`)
+}
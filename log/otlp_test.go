@@ -0,0 +1,70 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestOTLPEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerWithEncoder(&buf, ALL, false, NewOTLPEncoder("service.name", "grid", "service.version", "v1.2"))
+	ctx := ContextWithTrace(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	FromContext(NewContext(ctx, l.Named("csv"))).Warnw("bad row", "row", 7, "ratio", 0.5, "ok", false, "err", errors.New("eof"))
+
+	var req struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string
+					Value map[string]interface{}
+				}
+			}
+			ScopeLogs []struct {
+				Scope      struct{ Name string }
+				LogRecords []struct {
+					TimeUnixNano   string
+					SeverityNumber int
+					SeverityText   string
+					Body           struct{ StringValue string }
+					Attributes     []struct {
+						Key   string
+						Value map[string]interface{}
+					}
+					TraceID string `json:"traceId"`
+					SpanID  string `json:"spanId"`
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		t.Fatalf("Invalid JSON %s: %s", buf.String(), err)
+	}
+	res := req.ResourceLogs[0]
+	if len(res.Resource.Attributes) != 2 || res.Resource.Attributes[1].Value["stringValue"] != "v1.2" {
+		t.Errorf("Unexpected resource %+v", res.Resource)
+	}
+	scope := res.ScopeLogs[0]
+	r := scope.LogRecords[0]
+	if scope.Scope.Name != "csv" || r.SeverityNumber != 13 || r.SeverityText != "WARN" || r.Body.StringValue != "bad row" ||
+		r.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || r.SpanID != "00f067aa0ba902b7" || r.TimeUnixNano == "" {
+		t.Errorf("Unexpected log record %+v", r)
+	}
+	values := map[string]interface{}{}
+	for _, a := range r.Attributes {
+		for _, v := range a.Value {
+			values[a.Key] = v
+		}
+	}
+	want := map[string]interface{}{"row": "7", "ratio": 0.5, "ok": false, "err": "eof"}
+	for k, v := range want {
+		if values[k] != v {
+			t.Errorf("Attribute %s = %v, expected %v", k, values[k], v)
+		}
+	}
+	if _, ok := values[TraceIDKey]; ok || values["code.lineno"] == nil || values["code.function"] != "github.com/wlbr/commons/log.TestOTLPEncoder" {
+		t.Errorf("Unexpected attributes %v", values)
+	}
+}
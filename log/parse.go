@@ -0,0 +1,265 @@
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// textLine matches the lines written by the TextEncoder and the standard logger: the level,
+// the optional date and time and the optional 'file:line: ' part.
+var textLine = regexp.MustCompile(`^\s*(FATAL|ERROR|WARN|INFO|DEBUG|TRACE): ` +
+	`(?:(\d{4}/\d\d/\d\d) )?(?:(\d\d:\d\d:\d\d(?:\.\d+)?) )?(?:(\S+?):(\d+): )?(.*)$`)
+
+// ansiCodes matches terminal colour codes.
+var ansiCodes = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// ErrNoLogLine is returned for lines that were not written by one of the encoders.
+var ErrNoLogLine = errors.New("not a log line")
+
+// ParseLine reads back a line written by the TextEncoder, the JSONEncoder or the
+// LogfmtEncoder. The format is detected from the line. Times of text lines are read as UTC,
+// their fields and logger names can not be told apart from the message and are part of it.
+func ParseLine(line string) (*Record, error) {
+	line = strings.TrimRight(line, "\r\n")
+	switch {
+	case strings.HasPrefix(line, "{"):
+		return parseJSONLine(line)
+	case strings.HasPrefix(line, "time="):
+		return parseLogfmtLine(line)
+	}
+	return parseTextLine(line)
+}
+
+func parseTextLine(line string) (*Record, error) {
+	m := textLine.FindStringSubmatch(ansiCodes.ReplaceAllString(line, ""))
+	if m == nil {
+		return nil, ErrNoLogLine
+	}
+	level, _ := LogLevelString(m[1])
+	r := &Record{Level: level, File: m[4], Message: m[6]}
+	if m[2] != "" || m[3] != "" {
+		layout, value := "", ""
+		if m[2] != "" {
+			layout, value = "2006/01/02", m[2]
+		}
+		if m[3] != "" {
+			layout, value = strings.TrimSpace(layout+" 15:04:05"), strings.TrimSpace(value+" "+m[3])
+		}
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time in log line: %w", err)
+		}
+		r.Time = t
+	}
+	if m[5] != "" {
+		r.Line, _ = strconv.Atoi(m[5])
+	}
+	return r, nil
+}
+
+func parseJSONLine(line string) (*Record, error) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, ErrNoLogLine
+	}
+	r := &Record{}
+	header := map[string]bool{"time": true, "level": true, "file": true, "line": true, "logger": true, "msg": true}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if err := r.setKey(header, key, value); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func parseLogfmtLine(line string) (*Record, error) {
+	r := &Record{}
+	header := map[string]bool{"time": true, "level": true, "caller": true, "logger": true, "msg": true}
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimLeft(line, " ") {
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid logfmt pair at '%s'", line)
+		}
+		key := line[:eq]
+		line = line[eq+1:]
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			quoted, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				return nil, fmt.Errorf("invalid logfmt value of '%s': %w", key, err)
+			}
+			value, _ = strconv.Unquote(quoted)
+			line = line[len(quoted):]
+		} else {
+			end := strings.IndexByte(line, ' ')
+			if end < 0 {
+				end = len(line)
+			}
+			value, line = line[:end], line[end:]
+		}
+		if key == "caller" && header[key] {
+			delete(header, key)
+			file, lineno, _ := strings.Cut(value, ":")
+			r.File = file
+			r.Line, _ = strconv.Atoi(lineno)
+			continue
+		}
+		if err := r.setKey(header, key, value); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// setKey sets the Record member for the first occurrence of one of the header keys, other
+// keys are added as fields.
+func (r *Record) setKey(header map[string]bool, key string, value interface{}) error {
+	s, isString := value.(string)
+	if !header[key] {
+		r.Fields = append(r.Fields, Field{Key: key, Value: value})
+		return nil
+	}
+	delete(header, key)
+	switch {
+	case key == "time" && isString:
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return fmt.Errorf("invalid time in log line: %w", err)
+		}
+		r.Time = t
+	case key == "level" && isString:
+		level, err := LogLevelString(strings.ToUpper(s))
+		if err != nil {
+			return fmt.Errorf("invalid level in log line: %w", err)
+		}
+		r.Level = level
+	case key == "file" && isString:
+		r.File = s
+	case key == "line":
+		n, _ := strconv.Atoi(fmt.Sprint(value))
+		r.Line = n
+	case key == "logger" && isString:
+		r.Name = s
+	case key == "msg" && isString:
+		r.Message = s
+	default:
+		r.Fields = append(r.Fields, Field{Key: key, Value: value})
+	}
+	return nil
+}
+
+// A LineError is returned by Reader.Read for a line that can not be parsed. The Reader can
+// be used further afterwards.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// A Reader reads Records from a log file. Lines of text logs not starting with a level,
+// e.g. multi line messages or stack traces, are appended to the message of the previous
+// Record.
+type Reader struct {
+	rd      *bufio.Reader
+	follow  bool
+	partial string
+	next    string
+	hasNext bool
+	text    string
+	line    int
+}
+
+// NewReader creates a Reader reading from rd.
+func NewReader(rd io.Reader) *Reader {
+	return &Reader{rd: bufio.NewReader(rd)}
+}
+
+// NewFollowingReader creates a Reader for a file that is still written, like 'tail -f'.
+// Read returns io.EOF at the current end of the file, but keeps an incomplete last line and
+// may be called again once the file has grown. Lines continuing a Record that are written
+// after the Record was returned are returned as LineError.
+func NewFollowingReader(rd io.Reader) *Reader {
+	return &Reader{rd: bufio.NewReader(rd), follow: true}
+}
+
+// Read returns the next Record. It returns io.EOF at the end of the input. Lines that are
+// not log lines and do not follow one are returned as LineError wrapping ErrNoLogLine.
+func (rd *Reader) Read() (*Record, error) {
+	line, err := rd.nextLine()
+	if err != nil {
+		return nil, err
+	}
+	rd.text = line
+	r, err := ParseLine(line)
+	if err != nil {
+		return nil, &LineError{Line: rd.line, Err: err}
+	}
+	if strings.HasPrefix(line, "{") || strings.HasPrefix(line, "time=") {
+		return r, nil
+	}
+	for {
+		cont, err := rd.nextLine()
+		if err != nil {
+			break
+		}
+		if textLine.MatchString(ansiCodes.ReplaceAllString(cont, "")) || strings.HasPrefix(cont, "{") || strings.HasPrefix(cont, "time=") {
+			rd.next, rd.hasNext = cont, true
+			rd.line--
+			break
+		}
+		r.Message += "\n" + cont
+		rd.text += "\n" + cont
+	}
+	return r, nil
+}
+
+// Text returns the lines of the Record returned by the last call to Read.
+func (rd *Reader) Text() string {
+	return rd.text
+}
+
+// nextLine returns the next complete line. At the end of the input an incomplete last line
+// is returned, unless the Reader follows the file.
+func (rd *Reader) nextLine() (string, error) {
+	if rd.hasNext {
+		rd.hasNext = false
+		rd.line++
+		return rd.next, nil
+	}
+	s, err := rd.rd.ReadString('\n')
+	rd.partial += s
+	if err == io.EOF && !rd.follow && rd.partial != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimRight(rd.partial, "\r\n")
+	rd.partial = ""
+	rd.line++
+	return line, nil
+}
@@ -0,0 +1,88 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLineRoundTrip(t *testing.T) {
+	for _, enc := range []Encoder{&TextEncoder{Flags: loggerflags}, &JSONEncoder{}, &LogfmtEncoder{}} {
+		var buf bytes.Buffer
+		l := NewLoggerWithEncoder(&buf, ALL, true, enc).Named("csv")
+		l.Warnw("cannot parse", "row", 7, "file", "a b.csv")
+
+		r, err := ParseLine(buf.String())
+		if err != nil {
+			t.Fatalf("%T: %s", enc, err)
+		}
+		if r.Level != WARN || !strings.HasSuffix(r.File, "parse_test.go") || r.Line == 0 || time.Since(r.Time) > time.Minute {
+			t.Errorf("%T: unexpected record %+v", enc, r)
+		}
+		if _, ok := enc.(*TextEncoder); ok {
+			if r.Message != `csv: cannot parse row=7 file="a b.csv"` {
+				t.Errorf("Unexpected text message %q", r.Message)
+			}
+			continue
+		}
+		if r.Message != "cannot parse" || r.Name != "csv" || len(r.Fields) != 2 || r.Fields[1].Value != "a b.csv" {
+			t.Errorf("%T: unexpected record %+v", enc, r)
+		}
+	}
+	if _, err := ParseLine("just some output"); !errors.Is(err, ErrNoLogLine) {
+		t.Errorf("Expected ErrNoLogLine, got %v", err)
+	}
+}
+
+func TestReader(t *testing.T) {
+	input := " WARN: 2024/01/02 03:04:05.000006 /src/a.go:12: first\n" +
+		"goroutine 1 [running]:\n" +
+		"ERROR: 2024/01/02 03:04:06 second\n" +
+		`{"time":"2024-01-02T03:04:07Z","level":"INFO","file":"/src/b.go","line":3,"msg":"third"}` + "\n"
+	rd := NewReader(strings.NewReader("garbage\n" + input))
+	if _, err := rd.Read(); !errors.Is(err, ErrNoLogLine) {
+		t.Errorf("Expected ErrNoLogLine, got %v", err)
+	}
+	var records []*Record
+	for {
+		r, err := rd.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(records))
+	}
+	if r := records[0]; r.Message != "first\ngoroutine 1 [running]:" || r.Line != 12 ||
+		!r.Time.Equal(time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)) {
+		t.Errorf("Unexpected first record %+v", r)
+	}
+	if r := records[1]; r.Level != ERROR || r.File != "" || r.Message != "second" {
+		t.Errorf("Unexpected second record %+v", r)
+	}
+	if r := records[2]; r.Level != INFO || r.File != "/src/b.go" || r.Line != 3 {
+		t.Errorf("Unexpected third record %+v", r)
+	}
+}
+
+func TestFollowingReader(t *testing.T) {
+	var file bytes.Buffer
+	file.WriteString("INFO: first\nWARN: sec")
+	rd := NewFollowingReader(&file)
+	if r, err := rd.Read(); err != nil || r.Message != "first" {
+		t.Fatalf("Unexpected first record %+v, %v", r, err)
+	}
+	if _, err := rd.Read(); err != io.EOF {
+		t.Fatalf("Expected io.EOF for an incomplete line, got %v", err)
+	}
+	file.WriteString("ond\n")
+	if r, err := rd.Read(); err != nil || r.Level != WARN || r.Message != "second" {
+		t.Fatalf("Unexpected second record %+v, %v", r, err)
+	}
+}
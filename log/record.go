@@ -0,0 +1,72 @@
+package log
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// A Record is a single log message together with its meta data. It is handed to an
+// Encoder to be formatted. Name is the name of the logger, see Named.
+type Record struct {
+	Time    time.Time
+	Level   LogLevel
+	Message string
+	Fields  []Field
+	File    string
+	Line    int
+	PC      uintptr
+	Name    string
+	// template is the unformatted message, if known.
+	template string
+	// goroutine is the id of the logging goroutine, if recordGoroutines is set.
+	goroutine string
+}
+
+// recordGoroutines is set once a TemplateEncoder writes goroutine ids. Determining the id
+// is expensive, so it is only recorded if needed.
+var recordGoroutines atomic.Bool
+
+// newRecord creates a Record and determines the calling source file and line. calldepth
+// is handed to runtime.Caller, so newRecord itself counts as 0 and its caller as 1.
+func newRecord(calldepth int, level LogLevel, msg string, fieldLists ...[]Field) *Record {
+	r := &Record{Time: time.Now(), Level: level, Message: msg}
+	if recordGoroutines.Load() {
+		r.goroutine = goroutineID()
+	}
+	var pcs [1]uintptr
+	if runtime.Callers(calldepth+1, pcs[:]) > 0 {
+		r.PC = pcs[0]
+	}
+	r.File, r.Line = sourceOf(r.PC)
+	for _, fields := range fieldLists {
+		r.Fields = append(r.Fields, fields...)
+	}
+	return r
+}
+
+// sourceOf returns the source file and line of a program counter as returned by
+// runtime.Callers.
+func sourceOf(pc uintptr) (string, int) {
+	if pc == 0 {
+		return "???", 0
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.File == "" {
+		return "???", 0
+	}
+	return frame.File, frame.Line
+}
+
+// funcOf returns the full name of the function of a program counter as returned by
+// runtime.Callers.
+func funcOf(pc uintptr) string {
+	if pc == 0 {
+		return "???"
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.Function == "" {
+		return "???"
+	}
+	return frame.Function
+}
@@ -0,0 +1,502 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secret values in the log output.
+const Redacted = "[REDACTED]"
+
+// redactTag is the struct tag that marks secret struct fields: `log:"redact"`.
+const redactTag = "redact"
+
+// redactPattern is a regular expression and the replacement for its matches. If keywords
+// is set, the expression only runs on strings containing one of them, ignoring case.
+type redactPattern struct {
+	re          *regexp.Regexp
+	replacement string
+	keywords    []string
+}
+
+// redactor holds the redaction configuration of the package.
+type redactor struct {
+	mu       sync.RWMutex
+	keys     []string
+	patterns []redactPattern
+}
+
+var defaultRedactKeys = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "credential"}
+
+var redaction = &redactor{keys: defaultRedactKeys, patterns: []redactPattern{{
+	re:          regexp.MustCompile(`(?i)\b(password|passwd|secret|token|apikey|api_key)(\s*[=:]\s*)("[^"]*"|\S+)`),
+	replacement: "${1}${2}" + Redacted,
+	keywords:    []string{"password", "passwd", "secret", "token", "apikey", "api_key"},
+}}}
+
+// tagCache caches for every struct type whether it contains fields tagged `log:"redact"`.
+var tagCache sync.Map
+
+// SetRedactedKeys sets the key names whose values are masked, e.g. "password". A field is
+// masked if its key contains one of the names, ignoring the case of ASCII letters. Calling it
+// without names switches the masking by key off. By default password, passwd, secret, token,
+// apikey, api_key and credential are masked.
+func SetRedactedKeys(keys ...string) {
+	lower := make([]string, len(keys))
+	for i, k := range keys {
+		lower[i] = strings.ToLower(k)
+	}
+	redaction.mu.Lock()
+	redaction.keys = lower
+	redaction.mu.Unlock()
+}
+
+// AddRedactPattern adds a regular expression applied to all messages and string values.
+// Matches are replaced by replacement, which may refer to submatches like
+// regexp.ReplaceAllString. By default 'password=...', 'token: ...' and the like are masked.
+func AddRedactPattern(re *regexp.Regexp, replacement string) {
+	redaction.mu.Lock()
+	redaction.patterns = append(redaction.patterns, redactPattern{re: re, replacement: replacement})
+	redaction.mu.Unlock()
+}
+
+// ClearRedactPatterns removes all regular expressions, including the default one.
+func ClearRedactPatterns() {
+	redaction.mu.Lock()
+	redaction.patterns = nil
+	redaction.mu.Unlock()
+}
+
+func (rd *redactor) isSecretKey(key string) bool {
+	return containsAnyFold(key, rd.keys)
+}
+
+func (rd *redactor) redactString(s string) string {
+	for _, p := range rd.patterns {
+		if p.keywords != nil && !containsAnyFold(s, p.keywords) {
+			continue
+		}
+		s = p.re.ReplaceAllString(s, p.replacement)
+	}
+	return s
+}
+
+// containsAnyFold reports whether s contains one of the lower case ASCII keywords, ignoring
+// case. It is a cheap check that spares running a regular expression on most messages.
+func containsAnyFold(s string, keywords []string) bool {
+	for i := 0; i < len(s); i++ {
+		for _, k := range keywords {
+			if len(s)-i < len(k) {
+				continue
+			}
+			j := 0
+			for j < len(k) && lowerASCII(s[i+j]) == k[j] {
+				j++
+			}
+			if j == len(k) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// redactRecord masks secrets in the message and the fields of a Record. It is applied
+// before a Record is handed to any destination.
+func redactRecord(r *Record) {
+	redaction.mu.RLock()
+	defer redaction.mu.RUnlock()
+	r.Message = redaction.redactString(r.Message)
+	if len(r.Fields) == 0 {
+		return
+	}
+	// The fields are copied on the first change, they may be shared with the caller.
+	var fields []Field
+	for i, f := range r.Fields {
+		value, changed := f.Value, false
+		if redaction.isSecretKey(f.Key) {
+			value, changed = Redacted, true
+		} else if s, ok := f.Value.(string); ok {
+			value = redaction.redactString(s)
+			changed = value != s
+		} else {
+			value = redactValue(f.Value)
+			_, changed = value.(redactedValue)
+		}
+		if changed && fields == nil {
+			fields = make([]Field, len(r.Fields))
+			copy(fields, r.Fields)
+		}
+		if changed {
+			fields[i].Value = value
+		}
+	}
+	if fields != nil {
+		r.Fields = fields
+	}
+}
+
+// sprintf works like fmt.Sprintf, but struct arguments, and slices and maps of structs, are
+// printed with the fields tagged `log:"redact"` masked.
+func sprintf(format string, args []interface{}) string {
+	copied := false
+	for i, a := range args {
+		r := redactValue(a)
+		if _, ok := r.(redactedValue); !ok {
+			continue
+		}
+		if !copied {
+			args = append([]interface{}(nil), args...)
+			copied = true
+		}
+		args[i] = r
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// redactValue wraps structs containing fields tagged `log:"redact"`, and slices, arrays and
+// maps of them, so they are printed masked. Other values are returned unchanged.
+func redactValue(v interface{}) interface{} {
+	if v == nil {
+		return v
+	}
+	rv := reflect.ValueOf(v)
+	if !needsRedaction(rv) {
+		return v
+	}
+	return redactedValue{rv}
+}
+
+// needsRedaction reports whether v has to be masked. In addition to hasRedactedFields it
+// checks the elements of slices, arrays and maps of interfaces by their dynamic type.
+func needsRedaction(v reflect.Value) bool {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if hasRedactedFields(v.Type()) {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Interface {
+			for i := 0; i < v.Len(); i++ {
+				if needsRedaction(v.Index(i)) {
+					return true
+				}
+			}
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() == reflect.Interface {
+			iter := v.MapRange()
+			for iter.Next() {
+				if needsRedaction(iter.Value()) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// redactElem wraps a value taken from a struct, slice or map if it needs masking. Values
+// stored in interfaces are checked by their dynamic type.
+func redactElem(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if needsRedaction(v) {
+		return redactedValue{v}
+	}
+	return v
+}
+
+// hasRedactedFields reports whether t is a struct that contains fields tagged
+// `log:"redact"`, directly or in nested structs, or a pointer, slice, array or map of such
+// structs.
+func hasRedactedFields(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return hasRedactedFields(t.Elem())
+	case reflect.Map:
+		return hasRedactedFields(t.Key()) || hasRedactedFields(t.Elem())
+	case reflect.Struct:
+	default:
+		return false
+	}
+	if cached, ok := tagCache.Load(t); ok {
+		return cached.(bool)
+	}
+	tagCache.Store(t, false) // breaks cycles of recursive types
+	result := false
+	for i := 0; i < t.NumField() && !result; i++ {
+		f := t.Field(i)
+		result = f.Tag.Get("log") == redactTag || hasRedactedFields(f.Type)
+	}
+	tagCache.Store(t, result)
+	return result
+}
+
+// redactedValue prints a struct, slice, array or map like fmt does, with the tagged struct
+// fields masked.
+type redactedValue struct {
+	v reflect.Value
+}
+
+// Format implements fmt.Formatter. Pointers are dereferenced, a single '&' is written for
+// them. '%#v' prints the value in Go syntax.
+func (rv redactedValue) Format(f fmt.State, verb rune) {
+	v := rv.v
+	pointer := false
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			fmt.Fprint(f, "<nil>")
+			return
+		}
+		pointer = pointer || v.Kind() == reflect.Pointer
+		v = v.Elem()
+	}
+	goSyntax := verb == 'v' && f.Flag('#')
+	var buf bytes.Buffer
+	if pointer {
+		buf.WriteByte('&')
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		formatStruct(&buf, f, verb, v, goSyntax)
+	case reflect.Slice, reflect.Array:
+		formatList(&buf, f, verb, v, goSyntax)
+	case reflect.Map:
+		formatMap(&buf, f, verb, v, goSyntax)
+	default:
+		fmt.Fprintf(&buf, fmtVerb(f, verb), v)
+	}
+	f.Write(buf.Bytes())
+}
+
+func formatStruct(buf *bytes.Buffer, f fmt.State, verb rune, v reflect.Value, goSyntax bool) {
+	t := v.Type()
+	if goSyntax {
+		buf.WriteString(t.String())
+	}
+	buf.WriteByte('{')
+	for i := 0; i < t.NumField(); i++ {
+		if i > 0 && goSyntax {
+			buf.WriteString(", ")
+		} else if i > 0 {
+			buf.WriteByte(' ')
+		}
+		field := t.Field(i)
+		if f.Flag('+') || goSyntax {
+			buf.WriteString(field.Name)
+			buf.WriteByte(':')
+		}
+		switch {
+		case field.Tag.Get("log") == redactTag && goSyntax:
+			buf.WriteString(strconv.Quote(Redacted))
+		case field.Tag.Get("log") == redactTag:
+			buf.WriteString(Redacted)
+		default:
+			fmt.Fprintf(buf, fmtVerb(f, verb), redactElem(v.Field(i)))
+		}
+	}
+	buf.WriteByte('}')
+}
+
+func formatList(buf *bytes.Buffer, f fmt.State, verb rune, v reflect.Value, goSyntax bool) {
+	if goSyntax {
+		buf.WriteString(v.Type().String())
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteString("(nil)")
+			return
+		}
+		buf.WriteByte('{')
+	} else {
+		buf.WriteByte('[')
+	}
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 && goSyntax {
+			buf.WriteString(", ")
+		} else if i > 0 {
+			buf.WriteByte(' ')
+		}
+		fmt.Fprintf(buf, fmtVerb(f, verb), redactElem(v.Index(i)))
+	}
+	if goSyntax {
+		buf.WriteByte('}')
+	} else {
+		buf.WriteByte(']')
+	}
+}
+
+func formatMap(buf *bytes.Buffer, f fmt.State, verb rune, v reflect.Value, goSyntax bool) {
+	if goSyntax {
+		buf.WriteString(v.Type().String())
+		if v.IsNil() {
+			buf.WriteString("(nil)")
+			return
+		}
+		buf.WriteByte('{')
+	} else {
+		buf.WriteString("map[")
+	}
+	for i, key := range sortedKeys(v) {
+		if i > 0 && goSyntax {
+			buf.WriteString(", ")
+		} else if i > 0 {
+			buf.WriteByte(' ')
+		}
+		fmt.Fprintf(buf, fmtVerb(f, verb), redactElem(key))
+		buf.WriteByte(':')
+		fmt.Fprintf(buf, fmtVerb(f, verb), redactElem(v.MapIndex(key)))
+	}
+	if goSyntax {
+		buf.WriteByte('}')
+	} else {
+		buf.WriteByte(']')
+	}
+}
+
+// sortedKeys returns the keys of a map sorted like fmt prints them, numbers by value and
+// everything else by its printed form.
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint()
+		case reflect.Float32, reflect.Float64:
+			return a.Float() < b.Float()
+		case reflect.String:
+			return a.String() < b.String()
+		}
+		return fmt.Sprint(a) < fmt.Sprint(b)
+	})
+	return keys
+}
+
+// fmtVerb rebuilds the verb including the '+' and '#' flags.
+func fmtVerb(f fmt.State, verb rune) string {
+	switch {
+	case f.Flag('+'):
+		return "%+" + string(verb)
+	case f.Flag('#'):
+		return "%#" + string(verb)
+	}
+	return "%" + string(verb)
+}
+
+// MarshalJSON implements json.Marshaler. Exported struct fields are written using their
+// json names, tagged fields are masked.
+func (rv redactedValue) MarshalJSON() ([]byte, error) {
+	return marshalRedacted(rv.v)
+}
+
+func marshalRedacted(v reflect.Value) ([]byte, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return []byte("null"), nil
+		}
+		v = v.Elem()
+	}
+	if !needsRedaction(v) {
+		return json.Marshal(v.Interface())
+	}
+	var buf bytes.Buffer
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return []byte("null"), nil
+		}
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			b, err := marshalRedacted(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(b)
+		}
+		buf.WriteByte(']')
+	case reflect.Map:
+		if v.IsNil() {
+			return []byte("null"), nil
+		}
+		buf.WriteByte('{')
+		for i, key := range sortedKeys(v) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			name := fmt.Sprint(key)
+			if key.Kind() == reflect.String {
+				name = key.String()
+			}
+			k, _ := json.Marshal(name)
+			buf.Write(k)
+			buf.WriteByte(':')
+			b, err := marshalRedacted(v.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(b)
+		}
+		buf.WriteByte('}')
+	default:
+		buf.WriteByte('{')
+		t := v.Type()
+		first := true
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := field.Name
+			if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			key, _ := json.Marshal(name)
+			buf.Write(key)
+			buf.WriteByte(':')
+			b := []byte(strconv.Quote(Redacted))
+			if field.Tag.Get("log") != redactTag {
+				var err error
+				if b, err = marshalRedacted(v.Field(i)); err != nil {
+					return nil, err
+				}
+			}
+			buf.Write(b)
+		}
+		buf.WriteByte('}')
+	}
+	return buf.Bytes(), nil
+}
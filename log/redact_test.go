@@ -0,0 +1,126 @@
+package log
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type credentials struct {
+	User     string
+	Password string `log:"redact"`
+	port     int
+}
+
+type account struct {
+	Name  string
+	Login *credentials
+}
+
+func TestRedactKeysAndMessages(t *testing.T) {
+	var buf bytes.Buffer
+	sink := &bytes.Buffer{}
+	l := NewLoggerFromFile(&buf, ALL, false)
+	l.AddSink(NewSink(sink, ALL, false, &JSONEncoder{}))
+
+	l.Infow("connecting", "user", "bob", "db_password", "hunter2", "API-Token", 42)
+	l.Warn("login failed, password=hunter2 user=bob")
+	l.Info("Config: Token: \"abc def\"")
+
+	out := buf.String() + sink.String()
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "abc def") {
+		t.Errorf("Secret leaked: %s", out)
+	}
+	for _, want := range []string{"db_password=[REDACTED]", "API-Token=[REDACTED]", "user=bob",
+		"password=[REDACTED] user=bob", "Token: [REDACTED]", `"db_password":"[REDACTED]"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output: %s", want, out)
+		}
+	}
+}
+
+func TestRedactPattern(t *testing.T) {
+	saved := redaction.patterns
+	defer func() { redaction.patterns = saved }()
+	AddRedactPattern(regexp.MustCompile(`\b\d{4}-\d{4}-\d{4}-\d{4}\b`), "****")
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, ALL, false)
+	l.Infow("paid with 1234-5678-9012-3456", "card", "1234-5678-9012-3456")
+	if out := buf.String(); strings.Contains(out, "1234") || !strings.Contains(out, "paid with **** card=****") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+}
+
+func TestRedactStructTags(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, ALL, false)
+	c := &credentials{User: "bob", Password: "hunter2", port: 5432}
+	l.Info("%v %+v", c, account{Name: "main", Login: c})
+	l.Infow("login", "account", account{Name: "main", Login: c})
+
+	out := buf.String()
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("Secret leaked: %s", out)
+	}
+	for _, want := range []string{"&{bob [REDACTED] 5432}", "{Name:main Login:&{User:bob Password:[REDACTED] port:5432}}"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output: %s", want, out)
+		}
+	}
+
+	buf.Reset()
+	l.Info("%#v", c)
+	l.Info("%v", &c)
+	out = buf.String()
+	// The quotes of the masked field are removed by the default message pattern.
+	for _, want := range []string{`&log.credentials{User:"bob", Password:[REDACTED], port:5432}`, "&{bob [REDACTED] 5432}"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "PANIC") || strings.Contains(out, "hunter2") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+
+	buf.Reset()
+	l.Encoder = &JSONEncoder{}
+	l.Infow("login", "account", account{Name: "main", Login: c})
+	if want := `"account":{"Name":"main","Login":{"User":"bob","Password":"[REDACTED]"}}`; !strings.Contains(buf.String(), want) {
+		t.Errorf("Expected %s in output: %s", want, buf.String())
+	}
+}
+
+type vault struct {
+	List  []credentials
+	ByEnv map[string]*credentials
+}
+
+func TestRedactCollections(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, ALL, false)
+	list := []credentials{{User: "u", Password: "p6"}}
+	v := vault{List: list, ByEnv: map[string]*credentials{"prod": {User: "p", Password: "p7"}}}
+	l.Infow("m", "list", list, "any", []interface{}{list[0]})
+	l.Info("%s", list)
+	l.Info("%v", v)
+	l.Info("%#v", list)
+
+	out := buf.String()
+	for _, want := range []string{`list="[{u [REDACTED] 0}]"`, `any="[{u [REDACTED] 0}]"`, "[{u [REDACTED] %!s(int=0)}]",
+		"{[{u [REDACTED] 0}] map[prod:&{p [REDACTED] 0}]}", `[]log.credentials{log.credentials{User:"u", Password:`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output: %s", want, out)
+		}
+	}
+
+	buf.Reset()
+	l.Encoder = &JSONEncoder{}
+	l.Infow("m", "vault", v)
+	if want := `"vault":{"List":[{"User":"u","Password":"[REDACTED]"}],"ByEnv":{"prod":{"User":"p","Password":"[REDACTED]"}}}`; !strings.Contains(buf.String(), want) {
+		t.Errorf("Expected %s in output: %s", want, buf.String())
+	}
+	if out := buf.String(); strings.Contains(out, "p6") || strings.Contains(out, "p7") {
+		t.Errorf("Secret leaked: %s", out)
+	}
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"os"
+	"sync"
+)
+
+// A Reopener is a log destination that can close and reopen its file, e.g. after an
+// external tool like logrotate moved it away.
+type Reopener interface {
+	Reopen() error
+}
+
+// A ReopenableFile is an io.WriteCloser that appends to a named file and is able to reopen
+// that file by name. Writes and Reopen are serialized, so no line gets lost or torn apart
+// while the file is swapped. A ReopenableFile is safe for concurrent use.
+type ReopenableFile struct {
+	filename string
+	mu       sync.Mutex
+	file     *os.File
+}
+
+// NewReopenableFile opens (or creates) filename for appending.
+func NewReopenableFile(filename string) (*ReopenableFile, error) {
+	f, err := openLogFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &ReopenableFile{filename: filename, file: f}, nil
+}
+
+func openLogFile(filename string) (*os.File, error) {
+	return os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+}
+
+// Write implements io.Writer.
+func (r *ReopenableFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	return r.file.Write(p)
+}
+
+// Reopen opens the file by its name again and swaps it with the current one. The new file
+// is opened before the old one is closed, if opening fails the old file stays in use.
+func (r *ReopenableFile) Reopen() error {
+	f, err := openLogFile(r.filename)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	old := r.file
+	r.file = f
+	r.mu.Unlock()
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+// Close closes the file. Further writes fail with os.ErrClosed.
+func (r *ReopenableFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
@@ -0,0 +1,55 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestReopenableFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "tool.log")
+	f, err := NewReopenableFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLoggerFromFile(f, ALL, false)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				l.Info("line %d", i)
+			}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		os.Rename(name, filepath.Join(dir, "tool.log."+string(rune('a'+i))))
+		if err := f.Reopen(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	f.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "tool.log*"))
+	lines := 0
+	for _, n := range files {
+		content, _ := os.ReadFile(n)
+		for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+			if line == "" {
+				continue
+			}
+			if !strings.HasPrefix(line, " INFO: ") {
+				t.Errorf("Torn line in %s: %q", n, line)
+			}
+			lines++
+		}
+	}
+	if lines != 800 {
+		t.Errorf("Expected 800 lines, found %d", lines)
+	}
+}
@@ -0,0 +1,315 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rotation determines whether a RotatingWriter starts a new file after a fixed period.
+type Rotation int
+
+// The supported rotation periods.
+const (
+	RotateNever Rotation = iota
+	RotateHourly
+	RotateDaily
+)
+
+// rotationTimeFormat is used to build the names of rotated files. It sorts lexically.
+const rotationTimeFormat = "2006-01-02T15-04-05.000"
+
+// ParseRotation converts one of "none", "hourly" or "daily" into a Rotation. The name is
+// case insensitive, the empty string is taken as "none".
+func ParseRotation(s string) (Rotation, error) {
+	switch strings.ToLower(s) {
+	case "", "none", "never":
+		return RotateNever, nil
+	case "hourly":
+		return RotateHourly, nil
+	case "daily":
+		return RotateDaily, nil
+	}
+	return RotateNever, fmt.Errorf("unknown log rotation '%s'", s)
+}
+
+func (r Rotation) String() string {
+	switch r {
+	case RotateHourly:
+		return "hourly"
+	case RotateDaily:
+		return "daily"
+	}
+	return "none"
+}
+
+// periodStart returns the beginning of the rotation period t belongs to.
+func (r Rotation) periodStart(t time.Time) time.Time {
+	switch r {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// A RotatingWriter is an io.WriteCloser that writes into a file and moves it aside once
+// it reached its maximum size or a new rotation period has begun. Rotated files are named
+// like the file itself with a time stamp inserted before the extension, e.g.
+// 'tool-2024-05-01T13-00-00.000.log'. Only the latest rotated files are retained.
+// A RotatingWriter is safe for concurrent use.
+type RotatingWriter struct {
+	filename string
+	maxSize  int64
+	rotation Rotation
+	keep     int
+	compress bool
+
+	mu      sync.Mutex
+	file    *os.File
+	closed  bool
+	size    int64
+	period  time.Time
+	retry   time.Time
+	millMu  sync.Mutex
+	milling sync.WaitGroup
+	now     func() time.Time
+	rename  func(oldpath, newpath string) error
+}
+
+// rotateRetryDelay is the time a RotatingWriter waits after a failed rotation before it
+// rotates by size or period again.
+const rotateRetryDelay = time.Minute
+
+// NewRotatingWriter opens (or creates) filename for appending. maxSize is the maximum
+// file size in bytes before rotating, 0 switches size based rotation off. keep is the
+// number of rotated files to retain, 0 retains all of them. If compress is set, rotated
+// files are gzipped in the background.
+func NewRotatingWriter(filename string, maxSize int64, rotation Rotation, keep int, compress bool) (*RotatingWriter, error) {
+	w := &RotatingWriter{filename: filename, maxSize: maxSize, rotation: rotation, keep: keep,
+		compress: compress, now: time.Now, rename: os.Rename}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotatingWriter) open() error {
+	f, err := openLogFile(w.filename)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	if w.size > 0 {
+		w.period = w.rotation.periodStart(info.ModTime())
+	} else {
+		w.period = w.rotation.periodStart(w.now())
+	}
+	return nil
+}
+
+// Write implements io.Writer. A single write is never split across files.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.ensureOpen(); err != nil {
+		return 0, err
+	}
+	if w.needsRotation(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			w.retry = w.now().Add(rotateRetryDelay)
+			fmt.Fprintf(os.Stderr, "log: could not rotate '%s', retrying in %s: %s\n", w.filename, rotateRetryDelay, err)
+			if w.file == nil {
+				return 0, err
+			}
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *RotatingWriter) needsRotation(next int64) bool {
+	if w.size == 0 {
+		w.period = w.rotation.periodStart(w.now())
+		return false
+	}
+	if !w.retry.IsZero() && w.now().Before(w.retry) {
+		return false
+	}
+	if w.maxSize > 0 && w.size+next > w.maxSize {
+		return true
+	}
+	return w.rotation != RotateNever && w.rotation.periodStart(w.now()).After(w.period)
+}
+
+// Rotate moves the current file aside and starts a new one, regardless of its size.
+func (w *RotatingWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.ensureOpen(); err != nil {
+		return err
+	}
+	return w.rotate()
+}
+
+// ensureOpen opens the file again if a failed rotation left none open.
+func (w *RotatingWriter) ensureOpen() error {
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file == nil {
+		return w.open()
+	}
+	return nil
+}
+
+// rotate moves the current file aside and opens a new one. If the file can not be moved,
+// writing continues in the file opened by the same name, a new one if it was deleted. If no
+// file can be opened, the next write tries again.
+func (w *RotatingWriter) rotate() error {
+	f := w.file
+	w.file = nil
+	if err := f.Close(); err != nil {
+		return err
+	}
+	t := w.now()
+	rotated := w.rotatedName(t)
+	for exists(rotated) || exists(rotated+".gz") {
+		t = t.Add(time.Millisecond)
+		rotated = w.rotatedName(t)
+	}
+	renameErr := w.rename(w.filename, rotated)
+	if err := w.open(); err != nil {
+		return err
+	}
+	if os.IsNotExist(renameErr) {
+		// The file was deleted, writing continues in a new one.
+		w.retry = time.Time{}
+		return nil
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+	w.retry = time.Time{}
+	w.milling.Add(1)
+	go w.mill(rotated)
+	return nil
+}
+
+// rotatedName returns the name of a rotated file, e.g. 'dir/tool-<time stamp>.log'.
+func (w *RotatingWriter) rotatedName(t time.Time) string {
+	ext := filepath.Ext(w.filename)
+	return strings.TrimSuffix(w.filename, ext) + "-" + t.Format(rotationTimeFormat) + ext
+}
+
+func exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// mill compresses a freshly rotated file and removes rotated files exceeding keep.
+func (w *RotatingWriter) mill(rotated string) {
+	defer w.milling.Done()
+	w.millMu.Lock()
+	defer w.millMu.Unlock()
+	if w.compress {
+		if err := gzipFile(rotated); err != nil {
+			fmt.Fprintf(os.Stderr, "log: could not compress '%s': %s\n", rotated, err)
+		}
+	}
+	if w.keep > 0 {
+		old := w.rotatedFiles()
+		for len(old) > w.keep {
+			os.Remove(old[0])
+			old = old[1:]
+		}
+	}
+}
+
+// rotatedFiles returns the rotated files, oldest first.
+func (w *RotatingWriter) rotatedFiles() []string {
+	ext := filepath.Ext(w.filename)
+	base := strings.TrimSuffix(w.filename, ext) + "-"
+	matches, _ := filepath.Glob(base + "*" + ext)
+	gzipped, _ := filepath.Glob(base + "*" + ext + ".gz")
+	var files []string
+	for _, m := range append(matches, gzipped...) {
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(m, base), ".gz"), ext)
+		if _, err := time.Parse(rotationTimeFormat, stamp); err == nil {
+			files = append(files, m)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func gzipFile(name string) error {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(name+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err = io.Copy(zw, in); err == nil {
+		err = zw.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(name + ".gz")
+		return err
+	}
+	in.Close()
+	return os.Remove(name)
+}
+
+// Reopen opens the file by its name again, e.g. after it was moved by an external tool.
+// If opening fails the old file stays in use.
+func (w *RotatingWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	old := w.file
+	if err := w.open(); err != nil {
+		return err
+	}
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+// Close closes the current file and waits for pending compressions.
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	var err error
+	w.closed = true
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+	w.milling.Wait()
+	return err
+}
@@ -0,0 +1,120 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotateBySize(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "tool.log")
+	w, err := NewRotatingWriter(name, 20, RotateNever, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { clock = clock.Add(time.Second); return clock }
+	for i := 0; i < 5; i++ {
+		w.Write([]byte("0123456789abcde\n"))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rotated := w.rotatedFiles()
+	if len(rotated) != 2 {
+		t.Fatalf("Expected 2 retained files, got %v", rotated)
+	}
+	if filepath.Base(rotated[0]) >= filepath.Base(rotated[1]) || !strings.HasPrefix(filepath.Base(rotated[1]), "tool-2024-05-01T13-00-") {
+		t.Errorf("Unexpected names of rotated files: %v", rotated)
+	}
+	content, _ := os.ReadFile(name)
+	if string(content) != "0123456789abcde\n" {
+		t.Errorf("Unexpected content of current file: %q", content)
+	}
+}
+
+func TestRotateDailyCompressed(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "tool.log")
+	clock := time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC)
+	w, err := NewRotatingWriter(name, 0, RotateDaily, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return clock }
+	w.Write([]byte("day one\n"))
+	w.Write([]byte("still day one\n"))
+	clock = clock.Add(2 * time.Minute)
+	w.Write([]byte("day two\n"))
+	w.Close()
+	rotated := w.rotatedFiles()
+	if len(rotated) != 1 || !strings.HasSuffix(rotated[0], ".log.gz") {
+		t.Fatalf("Expected one compressed file, got %v", rotated)
+	}
+	content, _ := os.ReadFile(name)
+	if string(content) != "day two\n" {
+		t.Errorf("Unexpected content of current file: %q", content)
+	}
+}
+
+func TestRotateDeletedFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "tool.log")
+	w, err := NewRotatingWriter(name, 20, RotateNever, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	stderr := os.Stderr
+	os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	defer func() { os.Stderr = stderr }()
+
+	w.Write([]byte("0123456789abcde\n"))
+	os.Remove(name)
+	for _, line := range []string{"after delete\n", "next file\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed after the file was deleted: %s", err)
+		}
+	}
+	rotated := w.rotatedFiles()
+	if len(rotated) != 1 {
+		t.Fatalf("Expected 1 rotated file, got %v", rotated)
+	}
+	content, _ := os.ReadFile(rotated[0])
+	current, _ := os.ReadFile(name)
+	if string(content) != "after delete\n" || string(current) != "next file\n" {
+		t.Errorf("Unexpected content of rotated and current file: %q, %q", content, current)
+	}
+}
+
+func TestRotateFailingRename(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "tool.log")
+	w, err := NewRotatingWriter(name, 20, RotateNever, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	clock := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return clock }
+	renames := 0
+	w.rename = func(oldpath, newpath string) error { renames++; return os.ErrPermission }
+	stderr := os.Stderr
+	os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	defer func() { os.Stderr = stderr }()
+
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write([]byte("0123456789abcde\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if renames != 1 {
+		t.Errorf("Expected 1 rotation attempt, got %d", renames)
+	}
+	w.rename = os.Rename
+	clock = clock.Add(rotateRetryDelay)
+	w.Write([]byte("after the delay\n"))
+	content, _ := os.ReadFile(name)
+	if len(w.rotatedFiles()) != 1 || string(content) != "after the delay\n" {
+		t.Errorf("Expected a rotation after the delay, got %v and %q", w.rotatedFiles(), content)
+	}
+}
@@ -0,0 +1,186 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// A SamplingRule limits the number of similar messages of a level. Messages are similar if
+// they were logged with the same format string (or message for the structured functions)
+// by loggers of the same name. Within every Interval the First messages are written, after
+// that only every Thereafter-th message. A Thereafter of 0 drops all further messages.
+type SamplingRule struct {
+	Interval   time.Duration
+	First      int
+	Thereafter int
+}
+
+// errNeverDropped is returned for levels that must never be sampled or collapsed.
+func errNeverDropped(level LogLevel) error {
+	return fmt.Errorf("messages of level %s are never dropped", levelName(level))
+}
+
+type sampleKey struct {
+	level    LogLevel
+	name     string
+	template string
+}
+
+type sampleCounter struct {
+	start time.Time
+	n     int
+}
+
+// sampler holds the sampling rules of a Logger and the counters of similar messages. It is
+// shared between a Logger and its children.
+type sampler struct {
+	mu       sync.Mutex
+	rules    map[LogLevel]SamplingRule
+	counters map[sampleKey]*sampleCounter
+	dropped  uint64
+	now      func() time.Time
+}
+
+// SetSampling sets the SamplingRule for a level. A zero SamplingRule switches sampling off.
+// ERROR and FATAL messages are never sampled, an error is returned for them.
+func (l *Logger) SetSampling(level LogLevel, rule SamplingRule) error {
+	if level <= ERROR {
+		return errNeverDropped(level)
+	}
+	sp := l.target().sampler
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if sp.rules == nil {
+		sp.rules = make(map[LogLevel]SamplingRule)
+		sp.counters = make(map[sampleKey]*sampleCounter)
+	}
+	if rule == (SamplingRule{}) {
+		delete(sp.rules, level)
+	} else {
+		sp.rules[level] = rule
+	}
+	return nil
+}
+
+// SampledOut returns the number of messages dropped by sampling.
+func (l *Logger) SampledOut() uint64 {
+	sp := l.target().sampler
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return sp.dropped
+}
+
+// admit reports whether a Record passes the sampling rule of its level.
+func (sp *sampler) admit(r *Record) bool {
+	if r.Level <= ERROR {
+		return true
+	}
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	rule, ok := sp.rules[r.Level]
+	if !ok {
+		return true
+	}
+	key := sampleKey{level: r.Level, name: r.Name, template: r.template}
+	if key.template == "" {
+		key.template = r.Message
+	}
+	now := r.Time
+	if sp.now != nil {
+		now = sp.now()
+	}
+	c := sp.counters[key]
+	if c == nil || (rule.Interval > 0 && now.Sub(c.start) >= rule.Interval) {
+		c = &sampleCounter{start: now}
+		sp.counters[key] = c
+	}
+	c.n++
+	if c.n <= rule.First || (rule.Thereafter > 0 && (c.n-rule.First)%rule.Thereafter == 0) {
+		return true
+	}
+	sp.dropped++
+	return false
+}
+
+// -----------------------------
+
+// deduplicator collapses identical consecutive messages of the enabled levels into a single
+// 'last message repeated N times' message. It is shared between a Logger and its children.
+type deduplicator struct {
+	mu       sync.Mutex
+	levels   map[LogLevel]bool
+	last     *Record
+	repeated int
+}
+
+// SetDeduplication switches the collapsing of repeated messages for a level on or off. If a
+// message is identical to the previous one (same level, logger name, message and fields),
+// it is not written, instead a 'last message repeated N times' message is written as soon
+// as a different message arrives or the logger is flushed.
+// ERROR and FATAL messages are never collapsed, an error is returned for them.
+func (l *Logger) SetDeduplication(level LogLevel, on bool) error {
+	if level <= ERROR {
+		return errNeverDropped(level)
+	}
+	d := l.target().dedup
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.levels == nil {
+		d.levels = make(map[LogLevel]bool)
+	}
+	d.levels[level] = on
+	return nil
+}
+
+// check compares a Record to the previous one. It returns the pending 'repeated' Record, if
+// one has to be written before r, and whether r itself has to be written.
+func (d *deduplicator) check(r *Record) (repeated *Record, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.levels == nil {
+		return nil, true
+	}
+	if d.levels[r.Level] && d.last != nil && sameRecord(d.last, r) {
+		d.repeated++
+		return nil, false
+	}
+	repeated = d.pending()
+	d.last = nil
+	if d.levels[r.Level] {
+		d.last = r
+	}
+	return repeated, true
+}
+
+// flush returns the pending 'repeated' Record, if any, and forgets the last message.
+func (d *deduplicator) flush() *Record {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	repeated := d.pending()
+	d.last = nil
+	return repeated
+}
+
+func (d *deduplicator) pending() *Record {
+	if d.last == nil || d.repeated == 0 {
+		return nil
+	}
+	r := &Record{Time: time.Now(), Level: d.last.Level, Name: d.last.Name, File: d.last.File,
+		Line: d.last.Line, PC: d.last.PC, goroutine: d.last.goroutine,
+		Message: fmt.Sprintf("last message repeated %d times", d.repeated)}
+	d.repeated = 0
+	return r
+}
+
+func sameRecord(a, b *Record) bool {
+	if a.Level != b.Level || a.Name != b.Name || a.Message != b.Message || len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if a.Fields[i].Key != b.Fields[i].Key || fmt.Sprint(a.Fields[i].Value) != fmt.Sprint(b.Fields[i].Value) {
+			return false
+		}
+	}
+	return true
+}
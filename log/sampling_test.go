@@ -0,0 +1,73 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampling(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, ALL, false)
+	if err := l.SetSampling(ERROR, SamplingRule{First: 1}); err == nil {
+		t.Error("Sampling of ERROR should be refused")
+	}
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	l.sampler.now = func() time.Time { return clock }
+	l.SetSampling(WARN, SamplingRule{Interval: time.Second, First: 3, Thereafter: 10})
+	for i := 0; i < 25; i++ {
+		l.Warn("cell %d out of range", i)
+		l.Error("error %d", i)
+	}
+	clock = clock.Add(time.Second)
+	l.Warn("cell %d out of range", 99)
+
+	out := buf.String()
+	for _, expected := range []int{0, 1, 2, 12, 22, 99} {
+		if !strings.Contains(out, fmt.Sprintf("cell %d out of range\n", expected)) {
+			t.Errorf("Missing warning for cell %d", expected)
+		}
+	}
+	if n := strings.Count(out, "out of range"); n != 6 {
+		t.Errorf("Expected 6 sampled warnings, got %d", n)
+	}
+	if n := strings.Count(out, "ERROR: "); n != 25 {
+		t.Errorf("Errors were sampled, only %d written", n)
+	}
+	if l.SampledOut() != 20 {
+		t.Errorf("Expected 20 dropped messages, got %d", l.SampledOut())
+	}
+}
+
+func TestDeduplication(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, ALL, false)
+	if err := l.SetDeduplication(FATAL, true); err == nil {
+		t.Error("Deduplication of FATAL should be refused")
+	}
+	l.SetDeduplication(WARN, true)
+	for i := 0; i < 5; i++ {
+		l.Warn("grid full")
+		l.Error("boom")
+	}
+	for i := 0; i < 4; i++ {
+		l.Warn("grid full")
+	}
+	l.Info("different")
+	l.Warn("grid full")
+	l.Warn("grid full")
+	l.Flush()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 15 {
+		t.Fatalf("Expected 15 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasSuffix(lines[11], "last message repeated 3 times") || !strings.Contains(lines[11], "WARN: ") {
+		t.Errorf("Unexpected collapsed line: %s", lines[11])
+	}
+	if !strings.HasSuffix(lines[14], "last message repeated 1 times") {
+		t.Errorf("Pending repetition was not flushed: %s", lines[14])
+	}
+}
@@ -0,0 +1,125 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+)
+
+// A Sink is an additional destination of a Logger. Every Sink has its own LogLevel, colour
+// setting and Encoder, so a Logger may e.g. write warnings coloured to STDERR and all
+// messages uncoloured as JSON into a file at the same time.
+// A Sink with FollowLoggerLevel ignores its ActiveLoglevel and writes the messages passing
+// the level of the logger, including the levels set by SetLevel and SetLevelOverrides.
+// The fields of a Sink must not be changed once it was added to a Logger.
+type Sink struct {
+	Out               io.Writer
+	ActiveLoglevel    LogLevel
+	UseColouredOutput bool
+	Encoder           Encoder
+	FollowLoggerLevel bool
+	mu                sync.Mutex
+}
+
+// NewSink creates a Sink writing to out. A nil Encoder results in the classic text output.
+func NewSink(out io.Writer, level LogLevel, useColouredOutput bool, enc Encoder) *Sink {
+	return &Sink{Out: out, ActiveLoglevel: level, UseColouredOutput: useColouredOutput, Encoder: enc}
+}
+
+// NewSinkFromName creates a Sink for a file name, just like NewLogger does for a Logger.
+// The names "STDERR" and "STDOUT" denote the console. "syslog" denotes the local syslog
+// daemon, "syslog://host:port" a remote one reached by UDP and "journald" the systemd
+// journal, the Encoder and the colour setting are ignored for them.
+func NewSinkFromName(logfilename string, level LogLevel, useColouredOutput bool, enc Encoder) (*Sink, error) {
+	switch name := strings.ToLower(logfilename); {
+	case name == "syslog":
+		return NewSyslogSink("unixgram", "", level, FacilityUser)
+	case strings.HasPrefix(name, "syslog://"):
+		return NewSyslogSink("udp", logfilename[len("syslog://"):], level, FacilityUser)
+	case name == "journald":
+		return NewJournalSink("", level, "")
+	}
+	out, _, err := openDestination(logfilename)
+	if err != nil {
+		return nil, err
+	}
+	return NewSink(out, level, useColouredOutput, enc), nil
+}
+
+// isEnabled reports whether the Sink writes messages of the given level. logger is the
+// active level of the logger, limit caps the level of the Sink, it is used for the level
+// overrides of named loggers.
+func (s *Sink) isEnabled(level LogLevel, logger LogLevel, limit LogLevel) bool {
+	if s.FollowLoggerLevel {
+		return levelEnabled(logger, level)
+	}
+	active := s.ActiveLoglevel
+	if limit < active {
+		active = limit
+	}
+	return levelEnabled(active, level)
+}
+
+// Write encodes and writes a Record, if its level passes the level of the Sink.
+func (s *Sink) Write(r *Record) {
+	s.write(r, ALL, ALL)
+}
+
+func (s *Sink) write(r *Record, logger LogLevel, limit LogLevel) {
+	if s.isEnabled(r.Level, logger, limit) {
+		s.writeRecord(r)
+	}
+}
+
+// writeRecord encodes and writes a Record regardless of its level, with a single call to
+// the output.
+func (s *Sink) writeRecord(r *Record) {
+	enc := s.Encoder
+	if enc == nil {
+		enc = defaultEncoder
+	}
+	var buf bytes.Buffer
+	enc.Encode(&buf, r, s.UseColouredOutput)
+	s.mu.Lock()
+	s.Out.Write(buf.Bytes())
+	s.mu.Unlock()
+}
+
+// Close closes the output of the Sink, if it is an io.Closer. The console is never closed.
+func (s *Sink) Close() error {
+	return closeWriter(s.Out)
+}
+
+// sinkSet holds the additional sinks of a Logger. It is shared between a Logger and its
+// children, so sinks added to a parent are used by its children as well.
+type sinkSet struct {
+	mu    sync.RWMutex
+	sinks []*Sink
+}
+
+func (ss *sinkSet) add(s *Sink) {
+	ss.mu.Lock()
+	ss.sinks = append(ss.sinks, s)
+	ss.mu.Unlock()
+}
+
+func (ss *sinkSet) list() []*Sink {
+	if ss == nil {
+		return nil
+	}
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.sinks
+}
+
+// AddSink adds an additional destination to the Logger. Messages are written to the
+// loggers own stream and to every Sink whose level they pass.
+func (l *Logger) AddSink(s *Sink) {
+	l.target().sinks.add(s)
+}
+
+// Sinks returns the additional destinations of the Logger.
+func (l *Logger) Sinks() []*Sink {
+	return append([]*Sink(nil), l.target().sinks.list()...)
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSinks(t *testing.T) {
+	var console, file bytes.Buffer
+	l := NewLoggerFromFile(&console, WARN, true)
+	l.AddSink(NewSink(&file, ALL, false, &JSONEncoder{}))
+	child := l.With("job", 7)
+	child.Info("only in file")
+	child.Error("everywhere")
+
+	if strings.Contains(console.String(), "only in file") || !strings.Contains(console.String(), "everywhere job=7") {
+		t.Errorf("Unexpected console output: %s", console.String())
+	}
+	if !strings.Contains(console.String(), "\x1b[") {
+		t.Errorf("Console output should be coloured: %q", console.String())
+	}
+	lines := strings.Split(strings.TrimSpace(file.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"msg":"only in file","job":7`) || !strings.Contains(lines[1], `"level":"ERROR"`) {
+		t.Errorf("Unexpected file output: %s", file.String())
+	}
+}
+
+func TestSinkFiltersRecords(t *testing.T) {
+	var main, errs bytes.Buffer
+	l := NewLoggerFromFile(&main, OFF, false)
+	l.AddSink(NewSink(&errs, ERROR, false, nil))
+	l.Warn("dropped")
+	l.Fatal("kept")
+	if main.Len() != 0 {
+		t.Errorf("Logger with level OFF wrote: %s", main.String())
+	}
+	if strings.Contains(errs.String(), "dropped") || !strings.Contains(errs.String(), "FATAL: ") {
+		t.Errorf("Unexpected sink output: %s", errs.String())
+	}
+}
+
+func TestSinkFollowsLoggerLevel(t *testing.T) {
+	defer SetLevelOverrides(nil)
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&bytes.Buffer{}, WARN, false)
+	sink := NewSink(&buf, ERROR, false, nil)
+	sink.FollowLoggerLevel = true
+	l.AddSink(sink)
+
+	l.Info("hidden")
+	l.Warn("follows the logger")
+	l.SetLevel(INFO)
+	l.Info("follows SetLevel")
+	SetLevelOverrides(map[string]LogLevel{"csv": DEBUG})
+	l.Named("csv").Debug("follows the override")
+	l.Debug("hidden")
+
+	out := buf.String()
+	for _, want := range []string{"follows the logger", "follows SetLevel", "follows the override"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "hidden") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+}
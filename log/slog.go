@@ -0,0 +1,160 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// LevelFatal and LevelTrace are the slog.Levels that correspond to FATAL and TRACE. slog
+// itself does not know a level above slog.LevelError or below slog.LevelDebug.
+const (
+	LevelFatal = slog.LevelError + 4
+	LevelTrace = slog.LevelDebug - 4
+)
+
+// slogLevel maps a LogLevel to the corresponding slog.Level.
+func slogLevel(level LogLevel) slog.Level {
+	switch {
+	case level <= FATAL:
+		return LevelFatal
+	case level == ERROR:
+		return slog.LevelError
+	case level == WARN:
+		return slog.LevelWarn
+	case level == INFO:
+		return slog.LevelInfo
+	case level == DEBUG:
+		return slog.LevelDebug
+	}
+	return LevelTrace
+}
+
+// levelFromSlog maps a slog.Level to the LogLevel that covers it.
+func levelFromSlog(level slog.Level) LogLevel {
+	switch {
+	case level >= LevelFatal:
+		return FATAL
+	case level >= slog.LevelError:
+		return ERROR
+	case level >= slog.LevelWarn:
+		return WARN
+	case level >= slog.LevelInfo:
+		return INFO
+	case level >= slog.LevelDebug:
+		return DEBUG
+	}
+	return TRACE
+}
+
+// Handler is a slog.Handler that writes into a Logger. It honours the ActiveLoglevel,
+// the colour setting, the Encoder and the output stream of the Logger.
+type Handler struct {
+	logger *Logger
+	prefix string
+	fields []Field
+}
+
+// NewSlogHandler returns a slog.Handler that writes into l. Use it with slog.New or
+// slog.SetDefault to let programs using log/slog share the configuration of a Logger.
+func NewSlogHandler(l *Logger) *Handler {
+	return &Handler{logger: l}
+}
+
+// Enabled implements slog.Handler.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.logger.isEnabled(levelFromSlog(level))
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(ctx context.Context, sr slog.Record) error {
+	r := &Record{Time: sr.Time, Level: levelFromSlog(sr.Level), Message: sr.Message, PC: sr.PC}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	if recordGoroutines.Load() {
+		r.goroutine = goroutineID()
+	}
+	r.File, r.Line = sourceOf(sr.PC)
+	r.Name = h.logger.name
+	base := h.logger.baseFields()
+	r.Fields = make([]Field, 0, len(base)+len(h.fields)+sr.NumAttrs())
+	r.Fields = append(r.Fields, base...)
+	r.Fields = append(r.Fields, h.fields...)
+	r.Fields = append(r.Fields, contextFields(ctx)...)
+	sr.Attrs(func(a slog.Attr) bool {
+		r.Fields = appendAttr(r.Fields, h.prefix, a)
+		return true
+	})
+	h.logger.write(r)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.fields = append([]Field(nil), h.fields...)
+	for _, a := range attrs {
+		h2.fields = appendAttr(h2.fields, h.prefix, a)
+	}
+	return &h2
+}
+
+// WithGroup implements slog.Handler. Groups are flattened, the keys of the attributes
+// are prefixed with the group name and a dot.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// appendAttr converts an slog.Attr into Fields, groups are flattened.
+func appendAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix = prefix + a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, prefix, ga)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: prefix + a.Key, Value: a.Value.Any()})
+}
+
+// -----------------------------
+
+// NewLoggerFromHandler creates a Logger that forwards all messages into a slog.Handler.
+// Level filtering is left to the handler, ActiveLoglevel, colouring and the Encoder of
+// the Logger are not used.
+// Just as with NewLoggerFromFile the first created logger will be set to be the
+// convenience logger.
+func NewLoggerFromHandler(h slog.Handler) *Logger {
+	l := NewLoggerFromFile(nil, ALL, false)
+	l.handler = h
+	return l
+}
+
+// SetConvenienceHandler lets the convenience functions (Info, Warn, ...) forward all
+// messages into a slog.Handler, e.g. slog.Default().Handler().
+func SetConvenienceHandler(h slog.Handler) {
+	NewLoggerFromHandler(h).SetConvenienceLogger()
+}
+
+func (l *Logger) forwardToHandler(r *Record) {
+	sr := slog.NewRecord(r.Time, slogLevel(r.Level), r.Message, r.PC)
+	if r.Name != "" {
+		sr.AddAttrs(slog.String("logger", r.Name))
+	}
+	for _, f := range r.Fields {
+		sr.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	l.handler.Handle(context.Background(), sr)
+}
@@ -0,0 +1,42 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerFromFile(&buf, WARN, false)
+	sl := slog.New(NewSlogHandler(l)).With("job", 7).WithGroup("req")
+	sl.Info("filtered")
+	sl.Warn("slow", "ms", 350, slog.Group("user", "id", 1))
+	out := buf.String()
+	if strings.Contains(out, "filtered") {
+		t.Errorf("Message below ActiveLoglevel was written: %s", out)
+	}
+	if !strings.HasPrefix(out, " WARN: ") || !strings.Contains(out, "slow job=7 req.ms=350 req.user.id=1") {
+		t.Errorf("Unexpected output: %s", out)
+	}
+	if !strings.Contains(out, "slog_test.go:") {
+		t.Errorf("Output should name the calling file: %s", out)
+	}
+}
+
+func TestLoggerFromHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})
+	l := NewLoggerFromHandler(h)
+	l.Info("filtered")
+	l.With("job", 7).Errorw("failed", "tries", 3)
+	l.Fatal("down")
+	out := buf.String()
+	if strings.Contains(out, "filtered") {
+		t.Errorf("Message below the handlers level was written: %s", out)
+	}
+	if !strings.Contains(out, `level=ERROR msg=failed job=7 tries=3`) || !strings.Contains(out, `level=ERROR+4 msg=down`) {
+		t.Errorf("Unexpected output: %s", out)
+	}
+}
@@ -0,0 +1,133 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LogStats holds the number of messages written by a Logger, see Logger.Stats.
+type LogStats struct {
+	// Levels counts the messages per LogLevel.
+	Levels map[LogLevel]uint64
+	// Loggers counts the messages of the named loggers per name and LogLevel.
+	Loggers map[string]map[LogLevel]uint64
+	// Callers counts the messages per caller, given as 'file.go:42'.
+	Callers map[string]uint64
+}
+
+// Total returns the number of all messages.
+func (s LogStats) Total() uint64 {
+	var n uint64
+	for _, c := range s.Levels {
+		n += c
+	}
+	return n
+}
+
+// Errors returns the number of ERROR and FATAL messages.
+func (s LogStats) Errors() uint64 {
+	return s.Levels[FATAL] + s.Levels[ERROR]
+}
+
+// String summarises the counts per level, e.g. '12 messages (ERROR: 1, WARN: 3, INFO: 8)'.
+func (s LogStats) String() string {
+	var levels []string
+	for level := FATAL; level < ALL; level++ {
+		if n := s.Levels[level]; n > 0 {
+			levels = append(levels, fmt.Sprintf("%s: %d", level, n))
+		}
+	}
+	if len(levels) == 0 {
+		return fmt.Sprintf("%d messages", s.Total())
+	}
+	return fmt.Sprintf("%d messages (%s)", s.Total(), strings.Join(levels, ", "))
+}
+
+// statsCounter counts the messages of a Logger. It is shared between a Logger and its
+// children. Callers are kept by program counter and resolved when the stats are read.
+type statsCounter struct {
+	levels  [ALL + 1]atomic.Uint64
+	mu      sync.Mutex
+	loggers map[string]*[ALL + 1]uint64
+	callers map[uintptr]uint64
+}
+
+func (sc *statsCounter) add(r *Record) {
+	if r.Level < OFF || r.Level > ALL {
+		return
+	}
+	sc.levels[r.Level].Add(1)
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if r.Name != "" {
+		if sc.loggers == nil {
+			sc.loggers = map[string]*[ALL + 1]uint64{}
+		}
+		counts := sc.loggers[r.Name]
+		if counts == nil {
+			counts = &[ALL + 1]uint64{}
+			sc.loggers[r.Name] = counts
+		}
+		counts[r.Level]++
+	}
+	if sc.callers == nil {
+		sc.callers = map[uintptr]uint64{}
+	}
+	sc.callers[r.PC]++
+}
+
+func (sc *statsCounter) snapshot() LogStats {
+	s := LogStats{Levels: map[LogLevel]uint64{}, Loggers: map[string]map[LogLevel]uint64{},
+		Callers: map[string]uint64{}}
+	for level := range sc.levels {
+		if n := sc.levels[level].Load(); n > 0 {
+			s.Levels[LogLevel(level)] = n
+		}
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	for name, counts := range sc.loggers {
+		s.Loggers[name] = map[LogLevel]uint64{}
+		for level, n := range counts {
+			if n > 0 {
+				s.Loggers[name][LogLevel(level)] = n
+			}
+		}
+	}
+	for pc, n := range sc.callers {
+		file, line := sourceOf(pc)
+		s.Callers[shortFile(file)+":"+strconv.Itoa(line)] += n
+	}
+	return s
+}
+
+func (sc *statsCounter) reset() {
+	for level := range sc.levels {
+		sc.levels[level].Store(0)
+	}
+	sc.mu.Lock()
+	sc.loggers, sc.callers = nil, nil
+	sc.mu.Unlock()
+}
+
+// Stats returns the number of messages the Logger and its children have written since it
+// was created or ResetStats was called. Only messages passing the level of the logger or
+// of one of its sinks or hooks are counted, before they are sampled or deduplicated.
+func (l *Logger) Stats() LogStats {
+	return l.target().stats.snapshot()
+}
+
+// ResetStats sets all counters of the Logger to zero.
+func (l *Logger) ResetStats() {
+	l.target().stats.reset()
+}
+
+// -----------------------------
+
+// Stats returns the message counts of the convenience logger, see Logger.Stats.
+func Stats() LogStats {
+	return logger().Stats()
+}
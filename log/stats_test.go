@@ -0,0 +1,49 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	l := NewLoggerFromFile(&bytes.Buffer{}, WARN, false)
+	l.AddSink(NewSink(&bytes.Buffer{}, INFO, false, nil))
+	csv := l.Named("csv")
+
+	l.Debug("not counted")
+	l.Info("counted for the sink")
+	for i := 0; i < 3; i++ {
+		csv.Warn("row %d is empty", i)
+	}
+	csv.Error("cannot parse")
+	l.Fatal("giving up")
+
+	s := l.Stats()
+	if s.Total() != 6 || s.Errors() != 2 {
+		t.Errorf("Expected 6 messages and 2 errors, got %d and %d", s.Total(), s.Errors())
+	}
+	if s.Levels[DEBUG] != 0 || s.Levels[INFO] != 1 || s.Levels[WARN] != 3 {
+		t.Errorf("Unexpected level counts: %v", s.Levels)
+	}
+	if s.Loggers["csv"][WARN] != 3 || s.Loggers["csv"][ERROR] != 1 || len(s.Loggers) != 1 {
+		t.Errorf("Unexpected logger counts: %v", s.Loggers)
+	}
+	found := false
+	for caller, n := range s.Callers {
+		if strings.HasPrefix(caller, "stats_test.go:") && n == 3 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a caller with 3 messages, got %v", s.Callers)
+	}
+	if want := "6 messages (FATAL: 1, ERROR: 1, WARN: 3, INFO: 1)"; s.String() != want {
+		t.Errorf("Expected %q, got %q", want, s.String())
+	}
+
+	csv.ResetStats()
+	if s := l.Stats(); s.Total() != 0 || len(s.Callers) != 0 || s.String() != "0 messages" {
+		t.Errorf("Stats not reset: %v", s)
+	}
+}
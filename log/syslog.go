@@ -0,0 +1,194 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Syslog facilities as defined by RFC 5424.
+const (
+	FacilityUser   = 1
+	FacilityDaemon = 3
+	FacilityLocal0 = 16
+)
+
+// DefaultSyslogSocket is the local syslog socket.
+const DefaultSyslogSocket = "/dev/log"
+
+// syslogSDID is the id of the structured data element carrying source and fields. 32473 is
+// the private enterprise number reserved for documentation (RFC 5612).
+const syslogSDID = "fields@32473"
+
+// syslogSeverity maps a LogLevel to a syslog severity.
+func syslogSeverity(level LogLevel) int {
+	switch level {
+	case FATAL:
+		return 2 // critical
+	case ERROR:
+		return 3 // error
+	case WARN:
+		return 4 // warning
+	case INFO:
+		return 6 // informational
+	}
+	return 7 // debug
+}
+
+// SyslogEncoder writes every Record as an RFC 5424 syslog message. Source file, line,
+// logger name and fields are written as structured data.
+type SyslogEncoder struct {
+	Facility int
+	Hostname string
+	AppName  string
+	ProcID   string
+}
+
+// NewSyslogEncoder creates a SyslogEncoder for the facility, using the host name, the name
+// of the program and the process id.
+func NewSyslogEncoder(facility int) *SyslogEncoder {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "-"
+	}
+	return &SyslogEncoder{Facility: facility, Hostname: host, AppName: filepath.Base(os.Args[0]),
+		ProcID: strconv.Itoa(os.Getpid())}
+}
+
+// Encode implements Encoder.
+func (e *SyslogEncoder) Encode(buf *bytes.Buffer, r *Record, coloured bool) {
+	fmt.Fprintf(buf, "<%d>1 %s %s %s %s - ", e.Facility*8+syslogSeverity(r.Level),
+		r.Time.Format("2006-01-02T15:04:05.000000Z07:00"), syslogName(e.Hostname, 255),
+		syslogName(e.AppName, 48), syslogName(e.ProcID, 128))
+	buf.WriteString("[" + syslogSDID)
+	writeSDParam(buf, "file", r.File)
+	writeSDParam(buf, "line", strconv.Itoa(r.Line))
+	if r.Name != "" {
+		writeSDParam(buf, "logger", r.Name)
+	}
+	for _, f := range r.Fields {
+		writeSDParam(buf, f.Key, fmt.Sprint(f.Value))
+	}
+	buf.WriteString("] ")
+	buf.WriteString(r.Message)
+	buf.WriteByte('\n')
+}
+
+// syslogName returns s as a header field of at most max printable ASCII characters, '-'
+// if it is empty.
+func syslogName(s string, max int) string {
+	s = strings.Map(func(c rune) rune {
+		if c < 33 || c > 126 {
+			return -1
+		}
+		return c
+	}, s)
+	if len(s) > max {
+		s = s[:max]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// writeSDParam writes a structured data parameter. The name is cleaned of the characters
+// not allowed by RFC 5424, the value is escaped.
+func writeSDParam(buf *bytes.Buffer, name string, value string) {
+	name = strings.Map(func(c rune) rune {
+		if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			return '_'
+		}
+		return c
+	}, name)
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(name)
+	buf.WriteString(`="`)
+	for _, c := range value {
+		if c == '"' || c == '\\' || c == ']' {
+			buf.WriteByte('\\')
+		}
+		buf.WriteRune(c)
+	}
+	buf.WriteByte('"')
+}
+
+// NewSyslogSink creates a Sink sending RFC 5424 messages to a syslog daemon. network is
+// "unixgram" (or "unix") for a local socket, addr defaults to DefaultSyslogSocket then, or
+// "udp" for a remote daemon, e.g. "localhost:514". Every message is sent as one datagram.
+func NewSyslogSink(network, addr string, level LogLevel, facility int) (*Sink, error) {
+	if network == "unix" || network == "" {
+		network = "unixgram"
+	}
+	if addr == "" && network == "unixgram" {
+		addr = DefaultSyslogSocket
+	}
+	conn, err := dialDatagrams(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewSink(conn, level, false, NewSyslogEncoder(facility)), nil
+}
+
+// datagramConn is a connection to a syslog daemon or the journal that is dialled again
+// when a write fails, e.g. because the daemon was restarted, like log/syslog does.
+type datagramConn struct {
+	network string
+	addr    string
+	mu      sync.Mutex
+	conn    net.Conn
+	closed  bool
+}
+
+func dialDatagrams(network, addr string) (*datagramConn, error) {
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &datagramConn{network: network, addr: addr, conn: conn}, nil
+}
+
+// Write implements io.Writer. p is sent as one datagram. If sending fails, the connection
+// is dialled again and p is sent once more.
+func (c *datagramConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, os.ErrClosed
+	}
+	if c.conn != nil {
+		n, err := c.conn.Write(p)
+		if err == nil {
+			return n, nil
+		}
+		c.conn.Close()
+		c.conn = nil
+	}
+	conn, err := net.Dial(c.network, c.addr)
+	if err != nil {
+		return 0, err
+	}
+	c.conn = conn
+	return conn.Write(p)
+}
+
+// Close implements io.Closer. Writes after Close fail with os.ErrClosed.
+func (c *datagramConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
@@ -0,0 +1,90 @@
+package log
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listen opens a local datagram socket standing in for syslog or the journal.
+func listen(t *testing.T, network string) (net.PacketConn, string) {
+	t.Helper()
+	if network == "unixgram" && runtime.GOOS == "windows" {
+		t.Skip("no unix datagram sockets")
+	}
+	addr := "127.0.0.1:0"
+	if network == "unixgram" {
+		addr = filepath.Join(t.TempDir(), "socket")
+	}
+	pc, err := net.ListenPacket(network, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	return pc, pc.LocalAddr().String()
+}
+
+func receive(t *testing.T, pc net.PacketConn) string {
+	t.Helper()
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	b := make([]byte, 65536)
+	n, _, err := pc.ReadFrom(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b[:n])
+}
+
+func TestSyslogSink(t *testing.T) {
+	for _, network := range []string{"unixgram", "udp"} {
+		t.Run(network, func(t *testing.T) {
+			pc, addr := listen(t, network)
+			sink, err := NewSyslogSink(network, addr, INFO, FacilityDaemon)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sink.Close()
+			l := NewLoggerFromFile(&bytes.Buffer{}, OFF, false).Named("csv")
+			l.AddSink(sink)
+			l.Debug("not sent")
+			l.Warnw("bad row", "row", 7, "quote", `a "b"]`)
+
+			msg := receive(t, pc)
+			re := regexp.MustCompile(`^<28>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}\S+ \S+ \S+ \d+ - ` +
+				`\[fields@32473 file="\S+syslog_test.go" line="\d+" logger="csv" row="7" quote="a \\"b\\"\\]"\] bad row\n$`)
+			if !re.MatchString(msg) {
+				t.Errorf("Unexpected syslog message %q", msg)
+			}
+		})
+	}
+}
+
+func TestSyslogSinkRedial(t *testing.T) {
+	pc, addr := listen(t, "unixgram")
+	sink, err := NewSyslogSink("unixgram", addr, INFO, FacilityUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	l := NewLoggerFromFile(&bytes.Buffer{}, OFF, false)
+	l.AddSink(sink)
+
+	// The daemon is restarted and listens on a new socket of the same name.
+	pc.Close()
+	os.Remove(addr)
+	pc, err = net.ListenPacket("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	l.Info("after restart")
+	if msg := receive(t, pc); !strings.HasSuffix(msg, "] after restart\n") {
+		t.Errorf("Unexpected syslog message %q", msg)
+	}
+}
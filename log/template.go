@@ -0,0 +1,215 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTemplate is a template resembling the classic text output.
+const DefaultTemplate = "{level}: {time} {file}:{line}: {msg}{fields}"
+
+// timeLayouts are the named layouts accepted by the {time:...} token.
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"RFC822":      time.RFC822,
+	"RFC1123":     time.RFC1123,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"StampMilli":  time.StampMilli,
+	"StampMicro":  time.StampMicro,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+type templatePart func(buf *bytes.Buffer, r *Record, coloured bool)
+
+// TemplateEncoder writes every Record as a line following a template. The template is
+// text with tokens in braces, '{{' writes a single brace:
+//
+//	{time}            time as '2006/01/02 15:04:05.000000'
+//	{time:layout}     time in a named layout (RFC3339, RFC3339Nano, DateTime, Kitchen, ...),
+//	                  a Go time layout or unix, unixmilli for the epoch
+//	{level}           level name, coloured if colours are used
+//	{file}            full path of the source file, {file:short} the file name only
+//	{line}            source line
+//	{func}            full name of the function, {func:short} without the package path
+//	{goroutine}       id of the logging goroutine
+//	{name}            name of the logger, see Named
+//	{msg}             the message
+//	{fields}          the fields, each preceded by a space, as 'key=value'
+//
+// The fields are appended to the line if the template has no {fields} token.
+type TemplateEncoder struct {
+	parts    []templatePart
+	location *time.Location
+	fields   bool
+}
+
+// NewTemplateEncoder parses a template, see TemplateEncoder. The time is written in the
+// given location, UTC if loc is nil.
+func NewTemplateEncoder(template string, loc *time.Location) (*TemplateEncoder, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	e := &TemplateEncoder{location: loc}
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			s := text.String()
+			e.parts = append(e.parts, func(buf *bytes.Buffer, r *Record, coloured bool) { buf.WriteString(s) })
+			text.Reset()
+		}
+	}
+	for len(template) > 0 {
+		i := strings.IndexByte(template, '{')
+		if i < 0 {
+			text.WriteString(template)
+			break
+		}
+		text.WriteString(template[:i])
+		template = template[i:]
+		if strings.HasPrefix(template, "{{") {
+			text.WriteByte('{')
+			template = template[2:]
+			continue
+		}
+		end := strings.IndexByte(template, '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated token in template at '%s'", template)
+		}
+		part, err := e.token(template[1:end])
+		if err != nil {
+			return nil, err
+		}
+		flush()
+		e.parts = append(e.parts, part)
+		template = template[end+1:]
+	}
+	flush()
+	return e, nil
+}
+
+// token returns the part writing a single token.
+func (e *TemplateEncoder) token(token string) (templatePart, error) {
+	name, arg, _ := strings.Cut(token, ":")
+	switch {
+	case name == "time":
+		return e.timePart(arg), nil
+	case name == "level" && arg == "":
+		return func(buf *bytes.Buffer, r *Record, coloured bool) {
+			if coloured {
+				buf.WriteString(colorize(r.Level, levelName(r.Level)))
+			} else {
+				buf.WriteString(levelName(r.Level))
+			}
+		}, nil
+	case name == "file" && (arg == "" || arg == "short"):
+		return func(buf *bytes.Buffer, r *Record, coloured bool) {
+			if arg == "short" {
+				buf.WriteString(shortFile(r.File))
+			} else {
+				buf.WriteString(r.File)
+			}
+		}, nil
+	case name == "line" && arg == "":
+		return func(buf *bytes.Buffer, r *Record, coloured bool) {
+			buf.WriteString(strconv.Itoa(r.Line))
+		}, nil
+	case name == "func" && (arg == "" || arg == "short"):
+		return func(buf *bytes.Buffer, r *Record, coloured bool) {
+			fn := funcOf(r.PC)
+			if arg == "short" {
+				fn = shortFile(fn)
+			}
+			buf.WriteString(fn)
+		}, nil
+	case name == "goroutine" && arg == "":
+		recordGoroutines.Store(true)
+		return func(buf *bytes.Buffer, r *Record, coloured bool) {
+			if r.goroutine != "" {
+				buf.WriteString(r.goroutine)
+			} else {
+				buf.WriteString(goroutineID())
+			}
+		}, nil
+	case name == "name" && arg == "":
+		return func(buf *bytes.Buffer, r *Record, coloured bool) {
+			buf.WriteString(r.Name)
+		}, nil
+	case name == "msg" && arg == "":
+		return func(buf *bytes.Buffer, r *Record, coloured bool) {
+			buf.WriteString(r.Message)
+		}, nil
+	case name == "fields" && arg == "":
+		e.fields = true
+		return func(buf *bytes.Buffer, r *Record, coloured bool) {
+			buf.WriteString(formatFields(r.Fields))
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown token '{%s}' in template", token)
+}
+
+func (e *TemplateEncoder) timePart(layout string) templatePart {
+	switch layout {
+	case "":
+		layout = "2006/01/02 15:04:05.000000"
+	case "unix":
+		return func(buf *bytes.Buffer, r *Record, coloured bool) {
+			buf.WriteString(strconv.FormatInt(r.Time.Unix(), 10))
+		}
+	case "unixmilli":
+		return func(buf *bytes.Buffer, r *Record, coloured bool) {
+			buf.WriteString(strconv.FormatInt(r.Time.UnixMilli(), 10))
+		}
+	default:
+		if named, ok := timeLayouts[layout]; ok {
+			layout = named
+		}
+	}
+	return func(buf *bytes.Buffer, r *Record, coloured bool) {
+		buf.WriteString(r.Time.In(e.location).Format(layout))
+	}
+}
+
+// Encode implements Encoder.
+func (e *TemplateEncoder) Encode(buf *bytes.Buffer, r *Record, coloured bool) {
+	for _, part := range e.parts {
+		part(buf, r, coloured)
+	}
+	if !e.fields {
+		buf.WriteString(formatFields(r.Fields))
+	}
+	if buf.Len() == 0 || buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+}
+
+// ParseLocation returns the location for "utc", "local" or an IANA time zone name like
+// "Europe/Berlin".
+func ParseLocation(name string) (*time.Location, error) {
+	switch strings.ToLower(name) {
+	case "", "utc":
+		return time.UTC, nil
+	case "local":
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// goroutineID returns the id of the current goroutine, as shown in stack traces.
+func goroutineID() string {
+	var b [64]byte
+	s := string(b[:runtime.Stack(b[:], false)])
+	s = strings.TrimPrefix(s, "goroutine ")
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		return s[:i]
+	}
+	return "?"
+}
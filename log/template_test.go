@@ -0,0 +1,71 @@
+package log
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateEncoder(t *testing.T) {
+	berlin, err := ParseLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	enc, err := NewTemplateEncoder("[{time:RFC3339}] {level} {{{goroutine}} {file:short}:{line} {func:short} {name}| {msg}", berlin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	l := NewLoggerWithEncoder(&buf, ALL, false, enc).Named("csv")
+	l.Infow("parsed", "rows", 3)
+
+	r := &Record{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Level: WARN, Message: "m"}
+	var fixed bytes.Buffer
+	enc.Encode(&fixed, r, false)
+
+	re := regexp.MustCompile(`^\[\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\+0[12]:00\] INFO \{\d+\} template_test.go:\d+ log.TestTemplateEncoder csv\| parsed rows=3\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("Unexpected output: %q", buf.String())
+	}
+	if !strings.HasPrefix(fixed.String(), "[2024-01-02T04:04:05+01:00] WARN ") {
+		t.Errorf("Unexpected time: %q", fixed.String())
+	}
+}
+
+func TestTemplateGoroutineOfRecord(t *testing.T) {
+	enc, err := NewTemplateEncoder("{goroutine} {msg}", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newRecord(1, INFO, "logged here")
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		enc.Encode(&buf, r, false)
+		close(done)
+	}()
+	<-done
+	if want := goroutineID() + " logged here\n"; buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	for _, tmpl := range []string{"{msg", "{colour}", "{level:wide}"} {
+		if _, err := NewTemplateEncoder(tmpl, nil); err == nil {
+			t.Errorf("Expected an error for template %q", tmpl)
+		}
+	}
+	enc, err := NewTemplateEncoder(DefaultTemplate, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	enc.Encode(&buf, &Record{Time: time.Date(2024, 1, 2, 3, 4, 5, 6000, time.Local), Level: ERROR, Message: "m", File: "/a/b.go", Line: 7,
+		Fields: []Field{F("k", "v w")}}, false)
+	if want := "ERROR: " + time.Date(2024, 1, 2, 3, 4, 5, 6000, time.Local).UTC().Format("2006/01/02 15:04:05.000000") + " /a/b.go:7: m k=\"v w\"\n"; buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
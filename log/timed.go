@@ -0,0 +1,112 @@
+package log
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// scopeIndent is the indentation of a nested timed operation per level of nesting.
+const scopeIndent = "  "
+
+var (
+	slowThreshold atomic.Int64
+	scopesMu      sync.Mutex
+	// scopes holds the number of running timed operations per goroutine.
+	scopes = map[string]int{}
+)
+
+// SetSlowThreshold sets the duration above which timed operations are logged as WARN, see
+// Logger.Timed. 0 switches the escalation off, it is off by default.
+func SetSlowThreshold(d time.Duration) {
+	slowThreshold.Store(int64(d))
+}
+
+// SlowThreshold returns the duration set by SetSlowThreshold.
+func SlowThreshold() time.Duration {
+	return time.Duration(slowThreshold.Load())
+}
+
+// A Finisher ends an operation started by Logger.Timed. err points to the outcome of the
+// operation, usually a named result of the calling function, it may be nil. The fields are
+// added to those given to Timed.
+type Finisher func(err *error, keysAndValues ...interface{})
+
+// Timed starts a timed operation and returns the Finisher that ends it:
+//
+//	func (c *CSV) Load(name string) (err error) {
+//		defer log.Timed("load csv", "file", name)(&err)
+//
+// The start is logged as TRACE, the end as INFO with the fields duration and error. It is
+// logged as ERROR if the operation failed and as WARN if it took longer than the
+// SlowThreshold. Operations started while another one is running on the same goroutine are
+// nested, their messages are indented. Both messages are attributed to the call of Timed.
+func (l *Logger) Timed(name string, keysAndValues ...interface{}) Finisher {
+	return l.timed(3, name, SlowThreshold(), keysAndValues)
+}
+
+// TimedSlow works just as Timed, but with its own slow threshold instead of SlowThreshold.
+func (l *Logger) TimedSlow(name string, slow time.Duration, keysAndValues ...interface{}) Finisher {
+	return l.timed(3, name, slow, keysAndValues)
+}
+
+// timed starts a timed operation. calldepth is the number of stack frames up to the code
+// that started the operation, counting timed as 1.
+func (l *Logger) timed(calldepth int, name string, slow time.Duration, keysAndValues []interface{}) Finisher {
+	var pcs [1]uintptr
+	runtime.Callers(calldepth, pcs[:])
+	fields := toFields(keysAndValues)
+	gid := goroutineID()
+	scopesMu.Lock()
+	depth := scopes[gid]
+	scopes[gid]++
+	scopesMu.Unlock()
+	indent := strings.Repeat(scopeIndent, depth)
+
+	if l.isEnabled(TRACE) {
+		msg := indent + "> " + name
+		l.outputAt(pcs[0], TRACE, msg, msg, fields)
+	}
+	start := time.Now()
+	return func(err *error, keysAndValues ...interface{}) {
+		d := time.Since(start)
+		scopesMu.Lock()
+		if scopes[gid]--; scopes[gid] <= 0 {
+			delete(scopes, gid)
+		}
+		scopesMu.Unlock()
+
+		var outcome error
+		if err != nil {
+			outcome = *err
+		}
+		level := INFO
+		switch {
+		case outcome != nil:
+			level = ERROR
+		case slow > 0 && d > slow:
+			level = WARN
+		}
+		if !l.isEnabled(level) {
+			return
+		}
+		all := append(fields[:len(fields):len(fields)], F("duration", d), F("error", outcome))
+		msg := indent + "< " + name
+		l.outputAt(pcs[0], level, msg, msg, append(all, toFields(keysAndValues)...))
+	}
+}
+
+// -----------------------------
+
+// Timed starts a timed operation of the convenience logger, see Logger.Timed.
+func Timed(name string, keysAndValues ...interface{}) Finisher {
+	return logger().timed(3, name, SlowThreshold(), keysAndValues)
+}
+
+// TimedSlow starts a timed operation of the convenience logger with its own slow threshold,
+// see Logger.TimedSlow.
+func TimedSlow(name string, slow time.Duration, keysAndValues ...interface{}) Finisher {
+	return logger().timed(3, name, slow, keysAndValues)
+}
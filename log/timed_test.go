@@ -0,0 +1,57 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimed(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerWithEncoder(&buf, ALL, false, &LogfmtEncoder{})
+
+	load := func(name string) (err error) {
+		defer l.Timed("load", "file", name)(&err, "rows", 3)
+		func() {
+			defer l.Timed("parse")(nil)
+		}()
+		return errors.New("broken quote")
+	}
+	load("a.csv")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		`level=trace caller=timed_test.go:\d+ msg="> load" file=a.csv$`,
+		`level=trace caller=timed_test.go:\d+ msg="  > parse"$`,
+		`level=info caller=timed_test.go:\d+ msg="  < parse" duration=\S+ error=<nil>$`,
+		`level=error caller=timed_test.go:\d+ msg="< load" file=a.csv duration=\S+ error="broken quote" rows=3$`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("Expected %d lines, got:\n%s", len(want), buf.String())
+	}
+	for i, w := range want {
+		if !regexp.MustCompile(w).MatchString(lines[i]) {
+			t.Errorf("Line %d: expected %q, got %q", i, w, lines[i])
+		}
+	}
+	if len(scopes) != 0 {
+		t.Errorf("Scopes not released: %v", scopes)
+	}
+}
+
+func TestTimedSlow(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLoggerWithEncoder(&buf, INFO, false, &LogfmtEncoder{})
+	done := l.TimedSlow("query", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	done(nil)
+	l.TimedSlow("query", time.Hour)(nil)
+
+	out := buf.String()
+	if !strings.Contains(out, `level=warn`) || strings.Count(out, `level=info`) != 1 || strings.Contains(out, `msg="> `) {
+		t.Errorf("Unexpected output:\n%s", out)
+	}
+}